@@ -11,13 +11,25 @@ import (
 type Error struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// Reason is a machine-readable cause for errors that clients handle
+	// specially, such as ReasonEncryptedDocument.
+	Reason string `json:"reason,omitempty"`
 }
 
+// ReasonEncryptedDocument is the reason for documents that are encrypted
+// and could not be decrypted, so they were not scanned.
+const ReasonEncryptedDocument = "encrypted_document"
+
 // ErrorResponse sends a structured error response to the client and logs server errors.
 func ErrorResponse(w http.ResponseWriter, code int, message string) {
+	errorReasonResponse(w, code, "", message)
+}
+
+// errorReasonResponse sends a structured error response with a reason.
+func errorReasonResponse(w http.ResponseWriter, code int, reason, message string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(Error{Code: code, Message: message})
+	json.NewEncoder(w).Encode(Error{Code: code, Message: message, Reason: reason})
 
 	// Log error details for server errors
 	if code >= 500 {
@@ -3,12 +3,12 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
@@ -20,14 +20,36 @@ import (
 	"dws/s3"
 )
 
+// DefaultMaxBodySize is the default limit, in bytes, on uploaded and
+// downloaded documents.
+const DefaultMaxBodySize = 10 << 20
+
+// DefaultScanTimeout is the default time allowed for the rules engine to scan
+// one document.
+const DefaultScanTimeout = time.Minute
+
 var rulesFile string
 var llmAnalyzer *llm.Analyzer
+var maxBodySize int64 = DefaultMaxBodySize
+var scanTimeout = DefaultScanTimeout
 
 // SetRulesFile sets the rules file path for the api package.
 func SetRulesFile(path string) {
 	rulesFile = path
 }
 
+// SetScanTimeout sets how long the rules engine may spend on one document.
+// Zero disables the limit.
+func SetScanTimeout(d time.Duration) {
+	scanTimeout = d
+}
+
+// SetMaxBodySize sets the largest document, in bytes, the scan endpoints
+// accept. Larger documents are rejected with 413.
+func SetMaxBodySize(n int64) {
+	maxBodySize = n
+}
+
 // SetLLMAnalyzer sets the LLM analyzer for the api package.
 func SetLLMAnalyzer(analyzer *llm.Analyzer) {
 	llmAnalyzer = analyzer
@@ -36,6 +58,240 @@ func SetLLMAnalyzer(analyzer *llm.Analyzer) {
 type Report struct {
 	FileID   string          `json:"fileID"`
 	Findings []engine.Finding `json:"findings"`
+	// SuppressedCount is the number of findings dropped by allowlists and
+	// inline directives. The findings themselves are only included when
+	// include_suppressed=true.
+	SuppressedCount int              `json:"suppressed_count"`
+	Suppressed      []engine.Finding `json:"suppressed,omitempty"`
+	// Suppressions lists the inline dws:ignore directives in the document,
+	// marking stale ones that no longer suppress anything.
+	Suppressions []engine.Suppression `json:"suppressions,omitempty"`
+	// RiskScore (0-100) and MaxSeverity summarize every reported finding,
+	// including those hidden by min_severity.
+	RiskScore   int    `json:"risk_score"`
+	MaxSeverity string `json:"max_severity,omitempty"`
+	// Verdict is the ruleset policy's decision for the document: allow,
+	// warn, quarantine or block. VerdictReasons lists the thresholds that
+	// produced it.
+	Verdict        string   `json:"verdict"`
+	VerdictReasons []string `json:"verdict_reasons,omitempty"`
+	// Truncated is set when the scan hit the findings or per-rule match cap
+	// and some findings were dropped.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// newReport builds the report for a scan result, keeping findings at or
+// above min and including the suppressed findings if the request asked for
+// them.
+func newReport(r *http.Request, fileID string, result *engine.Result, min engine.Severity) Report {
+	report := Report{
+		FileID:          fileID,
+		Findings:        engine.FilterSeverity(result.Findings, min),
+		SuppressedCount: len(result.Suppressed),
+		Suppressions:    result.Suppressions,
+		RiskScore:       engine.RiskScore(result.Findings),
+		Verdict:         result.Decision.Verdict,
+		VerdictReasons:  result.Decision.Reasons,
+		Truncated:       result.Truncated,
+	}
+	if max := engine.MaxSeverity(result.Findings); max != engine.SeverityUnknown {
+		report.MaxSeverity = max.String()
+	}
+	if r.URL.Query().Get("include_suppressed") == "true" {
+		report.Suppressed = result.Suppressed
+	}
+	return report
+}
+
+var (
+	errInvalidMultipart = errors.New("invalid multipart")
+	errMissingFile      = errors.New("missing file")
+)
+
+// maxPasswordSize caps the length of a document password form field.
+const maxPasswordSize = 1024
+
+// extractOptions returns the extraction options set by the request's
+// html_attributes=true and html_comments=true query parameters.
+func extractOptions(r *http.Request) scanner.ExtractOptions {
+	q := r.URL.Query()
+	return scanner.ExtractOptions{
+		HTMLAttributes: q.Get("html_attributes") == "true",
+		HTMLComments:   q.Get("html_comments") == "true",
+	}
+}
+
+// upload is the document of a multipart scan request.
+type upload struct {
+	file io.Reader
+	name string
+	// opts carries the optional "password" field, which must precede the
+	// file since the upload is not buffered.
+	opts scanner.ExtractOptions
+}
+
+// formFile returns the "file" part of a multipart request and its name
+// without buffering the upload, so it can be streamed into the engine. The
+// request body is limited to the maximum body size.
+func formFile(w http.ResponseWriter, r *http.Request) (upload, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return upload{}, errInvalidMultipart
+	}
+	up := upload{opts: extractOptions(r)}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return upload{}, errMissingFile
+		}
+		if err != nil {
+			if tooLarge(err) {
+				return upload{}, err
+			}
+			return upload{}, errInvalidMultipart
+		}
+		switch {
+		case part.FormName() == "file" && part.FileName() != "":
+			up.file, up.name = part, part.FileName()
+			return up, nil
+		case part.FormName() == "password":
+			password, err := io.ReadAll(io.LimitReader(part, maxPasswordSize))
+			if err != nil {
+				return upload{}, errInvalidMultipart
+			}
+			up.opts.Password = string(password)
+		}
+	}
+}
+
+// parseMultipartForm parses a multipart request whose body is limited to the
+// maximum body size.
+func parseMultipartForm(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	if err := r.ParseMultipartForm(maxBodySize); err != nil {
+		if tooLarge(err) {
+			return err
+		}
+		return errInvalidMultipart
+	}
+	return nil
+}
+
+// tooLarge reports whether err comes from exceeding the maximum body size.
+func tooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+// bodyError writes the response for a failure reading a request, using
+// 413 when the body exceeded the maximum size and status otherwise.
+func bodyError(w http.ResponseWriter, err error, status int, message string) {
+	if tooLarge(err) {
+		ErrorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("document exceeds the %d byte limit", maxBodySize))
+		return
+	}
+	ErrorResponse(w, status, message)
+}
+
+// extractError writes the response for a document whose text could not be
+// extracted. Encrypted documents that could not be decrypted get a distinct
+// reason, so that callers can quarantine them instead of treating them as
+// clean.
+func extractError(w http.ResponseWriter, err error) {
+	if errors.Is(err, scanner.ErrEncryptedDocument) {
+		errorReasonResponse(w, http.StatusUnprocessableEntity, ReasonEncryptedDocument, err.Error())
+		return
+	}
+	bodyError(w, err, http.StatusBadRequest, "unsupported file")
+}
+
+// scanContext returns the context for scanning a request's document: the
+// request context, so that a client disconnect aborts the scan, limited to
+// the scan timeout.
+func scanContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if scanTimeout > 0 {
+		return context.WithTimeout(r.Context(), scanTimeout)
+	}
+	return context.WithCancel(r.Context())
+}
+
+// scanError writes the response for a scan stopped by its context, and
+// reports whether err was such an error.
+func scanError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		ErrorResponse(w, http.StatusServiceUnavailable, "scan exceeded the time limit")
+		return true
+	case errors.Is(err, context.Canceled):
+		logrus.WithField("url", r.URL.Path).Info("Scan cancelled by client")
+		return true
+	}
+	return false
+}
+
+// scanUpload streams the uploaded file through text extraction into the
+// rule set and writes the report.
+func scanUpload(w http.ResponseWriter, r *http.Request, set *engine.CompiledRuleSet, min engine.Severity) {
+	ctx, cancel := scanContext(r)
+	defer cancel()
+	up, err := formFile(w, r)
+	if err != nil {
+		bodyError(w, err, http.StatusBadRequest, err.Error())
+		return
+	}
+	filename := up.name
+	text, err := scanner.ExtractReaderContext(ctx, up.file, filename, up.opts)
+	if err != nil {
+		if !scanError(w, r, err) {
+			extractError(w, err)
+		}
+		return
+	}
+	result, err := set.ScanReader(ctx, text, filename)
+	if err != nil {
+		if !scanError(w, r, err) {
+			bodyError(w, err, http.StatusInternalServerError, "read error")
+		}
+		return
+	}
+	if engine.GetDebugMode() {
+		logrus.WithFields(logrus.Fields{
+			"file_id":    filename,
+			"findings":   result.Findings,
+			"suppressed": len(result.Suppressed),
+		}).Debug("Findings before encoding")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newReport(r, filename, result, min))
+}
+
+// minSeverity parses the optional min_severity query parameter. Without it
+// every finding is reported.
+func minSeverity(r *http.Request) (engine.Severity, error) {
+	value := r.URL.Query().Get("min_severity")
+	if value == "" {
+		return engine.SeverityUnknown, nil
+	}
+	sev, ok := engine.ParseSeverity(value)
+	if !ok {
+		return engine.SeverityUnknown, fmt.Errorf("invalid min_severity %q", value)
+	}
+	return sev, nil
+}
+
+// filterLLMFindings returns the LLM findings at or above min.
+func filterLLMFindings(findings []llm.LLMFinding, min engine.Severity) []llm.LLMFinding {
+	if min == engine.SeverityUnknown {
+		return findings
+	}
+	filtered := make([]llm.LLMFinding, 0, len(findings))
+	for _, f := range findings {
+		if sev, _ := engine.ParseSeverity(f.Severity); sev >= min {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
 }
 
 // EndpointDoc represents the documentation for a single API endpoint.
@@ -60,21 +316,44 @@ func DocsHandler(w http.ResponseWriter, r *http.Request) {
 		{
 			Path:        "/scan",
 			Method:      "POST",
-			Description: "Upload a document to be scanned and receive a structured report of findings including rule descriptions.",
+			Description: "Upload a document to be scanned and receive a structured report of findings including rule descriptions. Add include_suppressed=true to also list findings dropped by allowlists, and min_severity=high to only return findings at or above a severity. For HTML, html_attributes=true also scans alt, title and meta content values, and html_comments=true also scans comments.",
 			DataShapes: []DataShape{
 				{
 					Name:        "Request",
-					Description: "multipart/form-data",
-					Shape:       `{"file": "<file>"}`,
+					Description: "multipart/form-data. An optional password, sent before the file, decrypts encrypted PDFs.",
+					Shape:       `{"password": "<optional>", "file": "<file>"}`,
+				},
+				{
+					Name:        "Encrypted document error",
+					Description: "Returned with 422 when the document is encrypted and cannot be decrypted.",
+					Shape:       `{"code":422,"message":"encrypted document: a password is required","reason":"encrypted_document"}`,
 				},
 				{
 					Name:        "Response",
 					Description: "A structured report of findings.",
-					Shape:       `{"file_id":"uploaded-filename","findings":[{"rule_id":"rule-1","severity":"high","line":3,"context":"line containing match","description":"rule description"}]}`,
+					Shape:       `{"file_id":"uploaded-filename","findings":[{"rule_id":"rule-1","severity":"high","line":3,"column":12,"match":"secret","context":"text around the match","description":"rule description"}],"risk_score":7,"max_severity":"high","verdict":"warn","verdict_reasons":["1 finding(s)"],"suppressed_count":0}`,
 				},
 			},
 			CurlExample: `curl -X POST -F 'file=@/path/to/your/file.pdf' http://localhost:8080/scan`,
 		},
+		{
+			Path:        "/scan/redact",
+			Method:      "POST",
+			Description: "Scan a document and return its extracted text with each finding's match masked by its rule's redaction strategy (fixed, rule_id, partial or hash), alongside the findings report. Every unsuppressed finding is redacted; min_severity only limits the findings that are reported.",
+			DataShapes: []DataShape{
+				{
+					Name:        "Request",
+					Description: "multipart/form-data",
+					Shape:       `{"file": "<file>"}`,
+				},
+				{
+					Name:        "Response",
+					Description: "The findings report and the redacted text.",
+					Shape:       `{"file_id":"uploaded-filename","findings":[{"rule_id":"card-number","severity":"high","line":1,"match":"4111111111111111"}],"risk_score":7,"max_severity":"high","verdict":"warn","suppressed_count":0,"redacted_text":"card ************1111 on file"}`,
+				},
+			},
+			CurlExample: `curl -X POST -F 'file=@/path/to/your/file.txt' http://localhost:8080/scan/redact`,
+		},
 		{
 			Path:        "/rules/reload",
 			Method:      "POST",
@@ -82,8 +361,8 @@ func DocsHandler(w http.ResponseWriter, r *http.Request) {
 			DataShapes: []DataShape{
 				{
 					Name:        "Request",
-					Description: "A JSON object containing the new rules.",
-					Shape:       `{"rules":[{"id":"rule-1","pattern":"secret","severity":"high"}]}`,
+					Description: "A JSON object containing the new rules and an optional global allowlist.",
+					Shape:       `{"rules":[{"id":"rule-1","pattern":"secret","severity":"high"}],"allowlist":{"values":["secret sauce"]}}`,
 				},
 			},
 			CurlExample: `curl -X POST -H "Content-Type: application/json" -d '{\"rules\":[{\"id\":\"rule-1\",\"pattern\":\"secret\",\"severity\":\"high\"}]}' http://localhost:8080/rules/reload`,
@@ -101,6 +380,42 @@ func DocsHandler(w http.ResponseWriter, r *http.Request) {
 			},
 			CurlExample: `curl -X POST -H "Content-Type: application/json" -d '{\"path\":\"/etc/dws/rules.yaml\"}' http://localhost:8080/rules/load`,
 		},
+		{
+			Path:        "/rules/validate",
+			Method:      "POST",
+			Description: "Lint rules YAML without loading it, reporting invalid patterns, duplicate or missing IDs, unknown fields, unknown severities and patterns that match the empty string.",
+			DataShapes: []DataShape{
+				{
+					Name:        "Request",
+					Description: "The rules YAML as the request body.",
+					Shape:       `rules:\n  - id: rule-1\n    pattern: secret\n    severity: high`,
+				},
+				{
+					Name:        "Response",
+					Description: "Whether the rules are valid and the diagnostics found, with YAML line numbers.",
+					Shape:       `{"valid":false,"diagnostics":[{"level":"error","line":3,"column":5,"rule_id":"rule-1","message":"invalid pattern: error parsing regexp: missing closing ]"}]}`,
+				},
+			},
+			CurlExample: `curl -X POST --data-binary @rules.yaml http://localhost:8080/rules/validate`,
+		},
+		{
+			Path:        "/rules/test",
+			Method:      "POST",
+			Description: "Compile rules without loading them and check that each rule matches its examples.match texts and none of its examples.no_match texts.",
+			DataShapes: []DataShape{
+				{
+					Name:        "Request",
+					Description: "The rules YAML as the request body, or JSON with Content-Type application/json.",
+					Shape:       `rules:\n  - id: rule-1\n    pattern: secret\n    severity: high\n    examples:\n      match: ["top secret plan"]\n      no_match: ["secretary"]`,
+				},
+				{
+					Name:        "Response",
+					Description: "Pass and fail counts, one result per example, and the rules without examples.",
+					Shape:       `{"passed":1,"failed":1,"results":[{"rule_id":"rule-1","example":"secretary","expect":"no_match","passed":false,"message":"expected no match, matched \"secret\" on line 1"}],"untested":["rule-2"]}`,
+				},
+			},
+			CurlExample: `curl -X POST --data-binary @rules.yaml http://localhost:8080/rules/test`,
+		},
 		{
 			Path:        "/health",
 			Method:      "GET",
@@ -140,7 +455,7 @@ func DocsHandler(w http.ResponseWriter, r *http.Request) {
 				{
 					Name:        "Request",
 					Description: "JSON object with S3 URL and optional authentication parameters",
-					Shape:       `{"s3_url":"s3://bucket/path/file.pdf","region":"us-east-1","access_key_id":"optional","secret_access_key":"optional","session_token":"optional","role_arn":"optional"}`,
+					Shape:       `{"s3_url":"s3://bucket/path/file.pdf","region":"us-east-1","access_key_id":"optional","secret_access_key":"optional","session_token":"optional","role_arn":"optional","password":"optional"}`,
 				},
 				{
 					Name:        "Response",
@@ -213,7 +528,13 @@ func RulesetHandler(w http.ResponseWriter, r *http.Request) {
 
 	path := "rules/" + rule + ".yaml"
 
-	rules, err := engine.LoadRulesFromFile(path)
+	min, err := minSeverity(r)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	set, err := engine.LoadRuleSet(path)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"file":  path,
@@ -223,85 +544,86 @@ func RulesetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "invalid multipart")
-		return
-	}
-	file, header, err := r.FormFile("file")
+	scanUpload(w, r, set, min)
+}
+
+// ScanHandler ingests text and returns findings.
+func ScanHandler(w http.ResponseWriter, r *http.Request) {
+	min, err := minSeverity(r)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "missing file")
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	defer file.Close()
-	data, err := io.ReadAll(file)
+	scanUpload(w, r, engine.GetRuleSet(), min)
+}
+
+// RedactReport is a Report together with the redacted document text.
+type RedactReport struct {
+	Report
+	RedactedText string `json:"redacted_text"`
+}
+
+// RedactHandler scans a document and returns its extracted text with every
+// unsuppressed finding masked, whatever the reported min_severity.
+func RedactHandler(w http.ResponseWriter, r *http.Request) {
+	min, err := minSeverity(r)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, "read error")
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	text, err := scanner.ExtractText(data, header.Filename)
+	ctx, cancel := scanContext(r)
+	defer cancel()
+	up, err := formFile(w, r)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "unsupported file")
+		bodyError(w, err, http.StatusBadRequest, err.Error())
 		return
 	}
-	findings := engine.Evaluate(text, header.Filename, rules)
-	// Debug mode is available via engine.GetDebugMode if implemented
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(Report{FileID: header.Filename, Findings: findings})
-}
-
-// ScanHandler ingests text and returns findings.
-func ScanHandler(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "invalid multipart")
-		return
-	}
-	file, header, err := r.FormFile("file")
+	filename := up.name
+	extracted, err := scanner.ExtractReaderContext(ctx, up.file, filename, up.opts)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "missing file")
+		if !scanError(w, r, err) {
+			extractError(w, err)
+		}
 		return
 	}
-	defer file.Close()
-	data, err := io.ReadAll(file)
+	data, err := io.ReadAll(extracted)
 	if err != nil {
-		ErrorResponse(w, http.StatusInternalServerError, "read error")
+		bodyError(w, err, http.StatusInternalServerError, "read error")
 		return
 	}
-	text, err := scanner.ExtractText(data, header.Filename)
+	text := string(data)
+
+	set := engine.GetRuleSet()
+	result, err := set.ScanContext(ctx, text, filename)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "unsupported file")
+		scanError(w, r, err)
 		return
 	}
-	findings := engine.Evaluate(text, header.Filename, engine.GetRules())
-	if engine.GetDebugMode() {
-		logrus.WithFields(logrus.Fields{
-			"file_id":  header.Filename,
-			"findings": findings,
-		}).Debug("Findings before encoding")
+	// A truncated scan has dropped findings, so redacting it would leak them.
+	if result.Truncated {
+		ErrorResponse(w, http.StatusUnprocessableEntity, "document has too many findings to redact")
+		return
 	}
+	report := newReport(r, filename, result, min)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(Report{FileID: header.Filename, Findings: findings})
+	json.NewEncoder(w).Encode(RedactReport{
+		Report:       report,
+		RedactedText: set.Redact(text, result.Findings),
+	})
 }
 
 // ReloadRulesHandler replaces the current rule set.
 func ReloadRulesHandler(w http.ResponseWriter, r *http.Request) {
-	type request struct {
-		Rules []engine.Rule `json:"rules"`
-	}
-	var req request
+	var req engine.RulesConfig
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		ErrorResponse(w, http.StatusBadRequest, "invalid request")
 		return
 	}
 
-	for i := range req.Rules {
-		_, err := regexp.Compile(req.Rules[i].Pattern)
-		if err != nil {
-			ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("failed to compile regex for rule %s: %v", req.Rules[i].ID, err))
-			return
-		}
+	if err := engine.SetRulesConfig(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("failed to compile rules: %v", err))
+		return
 	}
-
-	engine.SetRules(req.Rules)
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -341,6 +663,53 @@ func LoadRulesFromFileHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": "rules loaded successfully"})
 }
 
+// ValidateRulesHandler lints the rules YAML in the request body without
+// loading it and returns the diagnostics found.
+func ValidateRulesHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		bodyError(w, err, http.StatusBadRequest, "read error")
+		return
+	}
+	diags := engine.LintRules(data, "")
+	if diags == nil {
+		diags = []engine.Diagnostic{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"valid":       !engine.HasErrors(diags),
+		"diagnostics": diags,
+	})
+}
+
+// RuleExamplesHandler compiles the rules in the request body without loading
+// them and checks each rule against its examples. The body is rules YAML, or
+// JSON when the Content-Type is application/json.
+func RuleExamplesHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		bodyError(w, err, http.StatusBadRequest, "read error")
+		return
+	}
+	config := &engine.RulesConfig{}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		err = json.Unmarshal(data, config)
+	} else {
+		config, err = engine.ParseRulesConfig(data)
+	}
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid rules")
+		return
+	}
+	set, err := engine.CompileRulesConfig(config)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("failed to compile rules: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set.TestExamples())
+}
+
 // S3ScanRequest represents a request to scan a file from S3
 type S3ScanRequest struct {
 	S3URL           string `json:"s3_url"`
@@ -349,10 +718,18 @@ type S3ScanRequest struct {
 	SecretAccessKey string `json:"secret_access_key,omitempty"`
 	SessionToken    string `json:"session_token,omitempty"`
 	RoleARN         string `json:"role_arn,omitempty"`
+	// Password decrypts an encrypted document.
+	Password string `json:"password,omitempty"`
 }
 
 // S3ScanHandler processes documents from S3 URLs
 func S3ScanHandler(w http.ResponseWriter, r *http.Request) {
+	min, err := minSeverity(r)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	var req S3ScanRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		ErrorResponse(w, http.StatusBadRequest, "invalid request body")
@@ -390,7 +767,7 @@ func S3ScanHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create context with timeout for the entire operation
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
 	defer cancel()
 
 	// Download file from S3 with detailed error handling
@@ -429,21 +806,22 @@ func S3ScanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check file size limits (10MB max)
-	const maxFileSize = 10 << 20 // 10 MB
-	if len(data) > maxFileSize {
+	// Check file size limits
+	if int64(len(data)) > maxBodySize {
 		logrus.WithFields(logrus.Fields{
 			"s3_url":   req.S3URL,
 			"filename": filename,
 			"size":     len(data),
-			"max_size": maxFileSize,
+			"max_size": maxBodySize,
 		}).Warn("File size exceeds maximum allowed")
-		ErrorResponse(w, http.StatusRequestEntityTooLarge, "file size exceeds 10MB limit")
+		ErrorResponse(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("document exceeds the %d byte limit", maxBodySize))
 		return
 	}
 
 	// Extract text from the downloaded file with timeout protection
-	text, err := scanner.ExtractText(data, filename)
+	opts := extractOptions(r)
+	opts.Password = req.Password
+	text, err := scanner.ExtractTextWith(data, filename, opts)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"s3_url":   req.S3URL,
@@ -451,6 +829,11 @@ func S3ScanHandler(w http.ResponseWriter, r *http.Request) {
 			"error":    err,
 		}).Error("Failed to extract text from S3 file")
 
+		if errors.Is(err, scanner.ErrEncryptedDocument) {
+			extractError(w, err)
+			return
+		}
+
 		if strings.Contains(err.Error(), "unsupported file format") {
 			ErrorResponse(w, http.StatusUnsupportedMediaType, fmt.Sprintf("unsupported file format: %s", err.Error()))
 			return
@@ -461,19 +844,26 @@ func S3ScanHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Process the text with the scanning engine
-	findings := engine.Evaluate(text, filename, engine.GetRules())
+	scanCtx, cancelScan := scanContext(r)
+	defer cancelScan()
+	result, err := engine.GetRuleSet().ScanContext(scanCtx, text, filename)
+	if err != nil {
+		scanError(w, r, err)
+		return
+	}
 
 	if engine.GetDebugMode() {
 		logrus.WithFields(logrus.Fields{
-			"s3_url":   req.S3URL,
-			"filename": filename,
-			"findings": findings,
+			"s3_url":     req.S3URL,
+			"filename":   filename,
+			"findings":   result.Findings,
+			"suppressed": len(result.Suppressed),
 		}).Debug("S3 scan findings before encoding")
 	}
 
 	// Return the results
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(Report{FileID: filename, Findings: findings})
+	json.NewEncoder(w).Encode(newReport(r, filename, result, min))
 }
 
 // HealthHandler reports service health.
@@ -495,8 +885,14 @@ func LLMScanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "invalid multipart")
+	min, err := minSeverity(r)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := parseMultipartForm(w, r); err != nil {
+		bodyError(w, err, http.StatusBadRequest, "invalid multipart")
 		return
 	}
 
@@ -513,9 +909,11 @@ func LLMScanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	text, err := scanner.ExtractText(data, header.Filename)
+	opts := extractOptions(r)
+	opts.Password = r.FormValue("password")
+	text, err := scanner.ExtractTextWith(data, header.Filename, opts)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "unsupported file")
+		extractError(w, err)
 		return
 	}
 
@@ -538,7 +936,7 @@ func LLMScanHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
 	defer cancel()
 
 	// Perform LLM analysis
@@ -551,6 +949,7 @@ func LLMScanHandler(w http.ResponseWriter, r *http.Request) {
 		ErrorResponse(w, http.StatusInternalServerError, "LLM analysis failed")
 		return
 	}
+	analysisResp.Findings = filterLLMFindings(analysisResp.Findings, min)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(analysisResp)
@@ -558,8 +957,13 @@ func LLMScanHandler(w http.ResponseWriter, r *http.Request) {
 
 // HybridScanHandler performs both regex and LLM analysis
 func HybridScanHandler(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "invalid multipart")
+	min, err := minSeverity(r)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := parseMultipartForm(w, r); err != nil {
+		bodyError(w, err, http.StatusBadRequest, "invalid multipart")
 		return
 	}
 
@@ -576,14 +980,23 @@ func HybridScanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	text, err := scanner.ExtractText(data, header.Filename)
+	opts := extractOptions(r)
+	opts.Password = r.FormValue("password")
+	text, err := scanner.ExtractTextWith(data, header.Filename, opts)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "unsupported file")
+		extractError(w, err)
 		return
 	}
 
 	// Perform regex analysis first
-	regexFindings := engine.Evaluate(text, header.Filename, engine.GetRules())
+	scanCtx, cancelScan := scanContext(r)
+	defer cancelScan()
+	result, err := engine.GetRuleSet().ScanContext(scanCtx, text, header.Filename)
+	if err != nil {
+		scanError(w, r, err)
+		return
+	}
+	regexFindings := engine.FilterSeverity(result.Findings, min)
 
 	// Create response object
 	response := map[string]interface{}{
@@ -593,7 +1006,7 @@ func HybridScanHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Perform LLM analysis if available
 	if llmAnalyzer != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
 		defer cancel()
 
 		// LLM analysis
@@ -609,6 +1022,7 @@ func HybridScanHandler(w http.ResponseWriter, r *http.Request) {
 				"error":    err,
 			}).Warn("LLM analysis failed in hybrid mode")
 		} else {
+			llmAnalysis.Findings = filterLLMFindings(llmAnalysis.Findings, min)
 			response["llm_analysis"] = llmAnalysis
 		}
 
@@ -639,8 +1053,13 @@ func HybridScanHandler(w http.ResponseWriter, r *http.Request) {
 
 // SmartScanHandler performs optimized analysis using rules as pre-filters
 func SmartScanHandler(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(10 << 20); err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "invalid multipart")
+	min, err := minSeverity(r)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := parseMultipartForm(w, r); err != nil {
+		bodyError(w, err, http.StatusBadRequest, "invalid multipart")
 		return
 	}
 
@@ -657,9 +1076,11 @@ func SmartScanHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	text, err := scanner.ExtractText(data, header.Filename)
+	opts := extractOptions(r)
+	opts.Password = r.FormValue("password")
+	text, err := scanner.ExtractTextWith(data, header.Filename, opts)
 	if err != nil {
-		ErrorResponse(w, http.StatusBadRequest, "unsupported file")
+		extractError(w, err)
 		return
 	}
 
@@ -672,15 +1093,19 @@ func SmartScanHandler(w http.ResponseWriter, r *http.Request) {
 			MinDocumentLength:    200,
 			MaxDocumentLength:    4000,
 			AnalyzeRuleTypes:     []string{"disease", "aggressive", "property"},
+			MinSeverity:          min,
 		}
 
 		smartAnalyzer := llm.NewSmartAnalyzer(llmAnalyzer, smartConfig)
 
-		ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+		ctx, cancel := context.WithTimeout(r.Context(), 90*time.Second)
 		defer cancel()
 
-		result, err := smartAnalyzer.AnalyzeWithPrefiltering(ctx, text, header.Filename, engine.GetRules())
+		result, err := smartAnalyzer.AnalyzeWithPrefiltering(ctx, text, header.Filename, engine.GetRuleSet())
 		if err != nil {
+			if scanError(w, r, err) {
+				return
+			}
 			logrus.WithFields(logrus.Fields{
 				"filename": header.Filename,
 				"error":    err,
@@ -693,7 +1118,14 @@ func SmartScanHandler(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(result)
 	} else {
 		// Fallback to regex-only
-		regexFindings := engine.Evaluate(text, header.Filename, engine.GetRules())
+		scanCtx, cancelScan := scanContext(r)
+		defer cancelScan()
+		result, err := engine.GetRuleSet().ScanContext(scanCtx, text, header.Filename)
+		if err != nil {
+			scanError(w, r, err)
+			return
+		}
+		regexFindings := engine.FilterSeverity(result.Findings, min)
 		response := map[string]interface{}{
 			"regex_findings":     regexFindings,
 			"llm_used":          false,
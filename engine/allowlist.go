@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Allowlist suppresses findings that are known false positives. It can be
+// declared for a whole ruleset or for a single rule.
+type Allowlist struct {
+	// Values are exact matched strings to ignore.
+	Values []string `json:"values,omitempty" yaml:"values"`
+	// Patterns are regexes matched against the lines containing a finding;
+	// a finding is suppressed when a pattern match overlaps it.
+	Patterns []string `json:"patterns,omitempty" yaml:"patterns"`
+	// Paths are file name globs (e.g. "*_test.go", "fixtures/*") to ignore.
+	Paths []string `json:"paths,omitempty" yaml:"paths"`
+	// Hashes are SHA-256 hex digests of matched values, optionally prefixed
+	// with "sha256:", so secrets can be allowlisted without storing them.
+	Hashes []string `json:"hashes,omitempty" yaml:"hashes"`
+}
+
+// compiledAllowlist is an Allowlist prepared for matching.
+type compiledAllowlist struct {
+	values   map[string]bool
+	patterns []*regexp.Regexp
+	paths    []string
+	hashes   map[string]bool
+}
+
+// compileAllowlist validates and compiles an allowlist. A nil or empty
+// allowlist compiles to nil.
+func compileAllowlist(a *Allowlist) (*compiledAllowlist, error) {
+	if a == nil || (len(a.Values) == 0 && len(a.Patterns) == 0 && len(a.Paths) == 0 && len(a.Hashes) == 0) {
+		return nil, nil
+	}
+	c := &compiledAllowlist{
+		values: make(map[string]bool, len(a.Values)),
+		hashes: make(map[string]bool, len(a.Hashes)),
+	}
+	for _, v := range a.Values {
+		c.values[v] = true
+	}
+	for _, p := range a.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist pattern: %w", err)
+		}
+		c.patterns = append(c.patterns, re)
+	}
+	for _, p := range a.Paths {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid allowlist path %q: %w", p, err)
+		}
+		c.paths = append(c.paths, p)
+	}
+	for _, h := range a.Hashes {
+		h = strings.ToLower(strings.TrimPrefix(h, "sha256:"))
+		if b, err := hex.DecodeString(h); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid allowlist hash %q: expected a SHA-256 hex digest", h)
+		}
+		c.hashes[h] = true
+	}
+	return c, nil
+}
+
+// suppresses reports whether the finding is allowlisted and, if so, why.
+func (c *compiledAllowlist) suppresses(f Finding, doc *document) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	if c.values[f.Match] {
+		return "value", true
+	}
+	if len(c.hashes) > 0 {
+		sum := sha256.Sum256([]byte(f.Match))
+		if c.hashes[hex.EncodeToString(sum[:])] {
+			return "hash", true
+		}
+	}
+	for _, p := range c.paths {
+		if pathMatches(p, f.FileID) {
+			return "path", true
+		}
+	}
+	if len(c.patterns) > 0 {
+		lo := doc.lineStart(f.Line)
+		lines := doc.slice(lo, doc.lineEnd(f.EndLine))
+		for _, re := range c.patterns {
+			for _, loc := range re.FindAllStringIndex(lines, -1) {
+				if lo+loc[0] < f.EndOffset && lo+loc[1] > f.StartOffset {
+					return "pattern", true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// pathMatches matches a glob against the full file ID and its base name.
+func pathMatches(glob, fileID string) bool {
+	if ok, _ := filepath.Match(glob, fileID); ok {
+		return true
+	}
+	ok, _ := filepath.Match(glob, filepath.Base(fileID))
+	return ok
+}
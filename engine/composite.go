@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"fmt"
+)
+
+// RuleTypeComposite combines the findings of other rules.
+const RuleTypeComposite = "composite"
+
+// CompositeOptions configures a composite rule. A composite fires when every
+// rule in All, at least one rule in Any (if set), and none of the rules in
+// None have findings near each other.
+type CompositeOptions struct {
+	All  []string `json:"all,omitempty" yaml:"all"`
+	Any  []string `json:"any,omitempty" yaml:"any"`
+	None []string `json:"none,omitempty" yaml:"none"`
+	// WithinLines and WithinChars bound the distance between contributing
+	// findings. When both are zero the whole document is considered.
+	WithinLines int `json:"within_lines,omitempty" yaml:"within_lines"`
+	WithinChars int `json:"within_chars,omitempty" yaml:"within_chars"`
+}
+
+// validateComposite checks the structure of a composite rule. References to
+// other rules are checked once the whole set is compiled.
+func validateComposite(rule Rule) error {
+	c := rule.Composite
+	if c == nil || (len(c.All) == 0 && len(c.Any) == 0) {
+		return fmt.Errorf("composite rule requires all or any rule references")
+	}
+	if c.WithinLines < 0 || c.WithinChars < 0 {
+		return fmt.Errorf("composite distances must not be negative")
+	}
+	return nil
+}
+
+// checkCompositeRefs verifies that every rule referenced by a composite is a
+// compiled, non-composite rule in the set.
+func checkCompositeRefs(rule Rule, available map[string]bool) error {
+	c := rule.Composite
+	for _, refs := range [][]string{c.All, c.Any, c.None} {
+		for _, id := range refs {
+			if !available[id] {
+				return fmt.Errorf("composite references unknown or invalid rule %q", id)
+			}
+		}
+	}
+	return nil
+}
+
+// near reports whether two findings are within the composite's distance limits.
+func (c *CompositeOptions) near(a, b Finding) bool {
+	if c.WithinLines > 0 {
+		if gap(a.Line, a.EndLine, b.Line, b.EndLine) > c.WithinLines {
+			return false
+		}
+	}
+	if c.WithinChars > 0 {
+		if gap(a.StartOffset, a.EndOffset, b.StartOffset, b.EndOffset) > c.WithinChars {
+			return false
+		}
+	}
+	return true
+}
+
+// gap returns the distance between the ranges [aStart, aEnd] and [bStart, bEnd],
+// or zero if they overlap.
+func gap(aStart, aEnd, bStart, bEnd int) int {
+	if bStart > aEnd {
+		return bStart - aEnd
+	}
+	if aStart > bEnd {
+		return aStart - bEnd
+	}
+	return 0
+}
+
+// closest returns the finding among candidates nearest to anchor that lies
+// within the composite's distance limits.
+func (c *CompositeOptions) closest(anchor Finding, candidates []Finding) (Finding, bool) {
+	var best Finding
+	bestGap := -1
+	for _, f := range candidates {
+		if !c.near(anchor, f) {
+			continue
+		}
+		g := gap(anchor.StartOffset, anchor.EndOffset, f.StartOffset, f.EndOffset)
+		if bestGap < 0 || g < bestGap {
+			best, bestGap = f, g
+		}
+	}
+	return best, bestGap >= 0
+}
+
+// evaluateComposite produces a finding for every anchor finding whose
+// neighbourhood satisfies the composite. Anchors are the findings of the
+// first All rule, or of every Any rule when All is empty.
+func evaluateComposite(cr compiledRule, byRule map[string][]Finding, fileID string, doc *document, contextChars int) []Finding {
+	c := cr.rule.Composite
+	var anchors []Finding
+	if len(c.All) > 0 {
+		anchors = byRule[c.All[0]]
+	} else {
+		for _, id := range c.Any {
+			anchors = append(anchors, byRule[id]...)
+		}
+	}
+
+	var findings []Finding
+	seen := make(map[[2]int]bool)
+	for _, anchor := range anchors {
+		contributing, ok := c.collect(anchor, byRule)
+		if !ok {
+			continue
+		}
+		start, end := anchor.StartOffset, anchor.EndOffset
+		for _, f := range contributing {
+			start = min(start, f.StartOffset)
+			end = max(end, f.EndOffset)
+		}
+		if seen[[2]int{start, end}] {
+			continue
+		}
+		seen[[2]int{start, end}] = true
+		f := cr.newFinding(fileID, doc, 0, []int{start, end}, contextChars)
+		f.Contributing = contributing
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// collect gathers the findings that satisfy the composite around anchor.
+func (c *CompositeOptions) collect(anchor Finding, byRule map[string][]Finding) ([]Finding, bool) {
+	contributing := []Finding{anchor}
+	if len(c.All) > 0 {
+		for _, id := range c.All[1:] {
+			f, ok := c.closest(anchor, byRule[id])
+			if !ok {
+				return nil, false
+			}
+			contributing = append(contributing, f)
+		}
+		if len(c.Any) > 0 {
+			var candidates []Finding
+			for _, id := range c.Any {
+				candidates = append(candidates, byRule[id]...)
+			}
+			f, ok := c.closest(anchor, candidates)
+			if !ok {
+				return nil, false
+			}
+			contributing = append(contributing, f)
+		}
+	}
+	for _, id := range c.None {
+		if _, ok := c.closest(anchor, byRule[id]); ok {
+			return nil, false
+		}
+	}
+	return contributing, true
+}
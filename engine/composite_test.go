@@ -0,0 +1,78 @@
+package engine
+
+import "testing"
+
+func compositeTestRules(composite CompositeOptions) []Rule {
+	return []Rule{
+		{ID: "banner", Pattern: `^SECRET\b`, Severity: "high"},
+		{ID: "noforn", Pattern: `NOFORN`, Severity: "high"},
+		{ID: "ssn", Pattern: `\d{3}-\d{2}-\d{4}`, Severity: "medium"},
+		{ID: "social", Pattern: `(?i)social`, Severity: "low"},
+		{ID: "sample", Pattern: `(?i)sample`, Severity: "low"},
+		{ID: "combo", Type: RuleTypeComposite, Severity: "critical", Composite: &composite},
+	}
+}
+
+func compositeFindings(findings []Finding) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if f.RuleID == "combo" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestCompositeAllWithinLines(t *testing.T) {
+	rules := compositeTestRules(CompositeOptions{All: []string{"banner", "noforn"}, WithinLines: 2})
+
+	text := "SECRET//NF\nline\n(S//NF) NOFORN\n\n\n\nSECRET\n1\n2\n3\nNOFORN"
+	combos := compositeFindings(Evaluate(text, "doc.txt", rules))
+
+	if len(combos) != 1 {
+		t.Fatalf("Expected 1 composite finding, got %d: %+v", len(combos), combos)
+	}
+	c := combos[0]
+	if c.Severity != "critical" || c.Line != 1 || c.EndLine != 3 {
+		t.Errorf("Unexpected composite finding: %+v", c)
+	}
+	if len(c.Contributing) != 2 || c.Contributing[0].RuleID != "banner" || c.Contributing[1].RuleID != "noforn" {
+		t.Errorf("Unexpected contributing findings: %+v", c.Contributing)
+	}
+}
+
+func TestCompositeNearWithinChars(t *testing.T) {
+	rules := compositeTestRules(CompositeOptions{All: []string{"ssn", "social"}, WithinChars: 20})
+
+	text := "Social Security Number: 123-45-6789\nOrder number 987-65-4321 is unrelated to the social event"
+	combos := compositeFindings(Evaluate(text, "doc.txt", rules))
+
+	if len(combos) != 1 || combos[0].Line != 1 {
+		t.Fatalf("Expected 1 composite finding on line 1, got %+v", combos)
+	}
+}
+
+func TestCompositeAnyAndNone(t *testing.T) {
+	rules := compositeTestRules(CompositeOptions{Any: []string{"ssn"}, None: []string{"sample"}, WithinLines: 1})
+
+	text := "SAMPLE DATA\n123-45-6789\n\n\n987-65-4321"
+	combos := compositeFindings(Evaluate(text, "doc.txt", rules))
+
+	if len(combos) != 1 || combos[0].Line != 5 {
+		t.Fatalf("Expected 1 composite finding on line 5, got %+v", combos)
+	}
+}
+
+func TestCompositeInvalidReferences(t *testing.T) {
+	testCases := []Rule{
+		{ID: "c", Type: RuleTypeComposite},
+		{ID: "c", Type: RuleTypeComposite, Composite: &CompositeOptions{All: []string{"missing"}}},
+		{ID: "c", Type: RuleTypeComposite, Composite: &CompositeOptions{All: []string{"a"}, WithinLines: -1}},
+	}
+	for _, rule := range testCases {
+		rules := []Rule{{ID: "a", Pattern: "a"}, rule}
+		if _, err := CompileRules(rules); err == nil {
+			t.Errorf("Expected error for composite %+v", rule.Composite)
+		}
+	}
+}
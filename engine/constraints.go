@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// maxLookaround is how many bytes before and after a match not_preceded_by
+// and not_followed_by see. Bounding the context keeps each check constant
+// time, however long the scope the rule matches in.
+const maxLookaround = 256
+
+// matchConstraints holds the compiled lookaround replacements for a rule.
+// Each field is nil when the rule does not set the corresponding option.
+type matchConstraints struct {
+	notFollowedBy *regexp.Regexp
+	notPrecededBy *regexp.Regexp
+	exclude       *regexp.Regexp
+}
+
+// compileConstraints compiles a rule's not_followed_by, not_preceded_by and
+// exclude_pattern options. The followed/preceded patterns are anchored to the
+// match boundary so they behave like (?!...) and (?<!...).
+func compileConstraints(rule Rule) (*matchConstraints, error) {
+	if rule.NotFollowedBy == "" && rule.NotPrecededBy == "" && rule.ExcludePattern == "" {
+		return nil, nil
+	}
+	c := &matchConstraints{}
+	var err error
+	if rule.NotFollowedBy != "" {
+		if c.notFollowedBy, err = regexp.Compile(`^(?:` + rule.NotFollowedBy + `)`); err != nil {
+			return nil, fmt.Errorf("invalid not_followed_by: %w", err)
+		}
+	}
+	if rule.NotPrecededBy != "" {
+		if c.notPrecededBy, err = regexp.Compile(`(?:` + rule.NotPrecededBy + `)$`); err != nil {
+			return nil, fmt.Errorf("invalid not_preceded_by: %w", err)
+		}
+	}
+	if rule.ExcludePattern != "" {
+		if c.exclude, err = regexp.Compile(rule.ExcludePattern); err != nil {
+			return nil, fmt.Errorf("invalid exclude_pattern: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// allows reports whether the match text[start:end] satisfies the constraints.
+// The lookarounds see at most maxLookaround bytes on each side of the match,
+// trimmed to whole characters.
+func (c *matchConstraints) allows(text string, start, end int) bool {
+	if c == nil {
+		return true
+	}
+	if c.notFollowedBy != nil {
+		after := min(end+maxLookaround, len(text))
+		for after > end && after < len(text) && !utf8.RuneStart(text[after]) {
+			after--
+		}
+		if c.notFollowedBy.MatchString(text[end:after]) {
+			return false
+		}
+	}
+	if c.notPrecededBy != nil {
+		before := max(start-maxLookaround, 0)
+		for before < start && !utf8.RuneStart(text[before]) {
+			before++
+		}
+		if c.notPrecededBy.MatchString(text[before:start]) {
+			return false
+		}
+	}
+	if c.exclude != nil && c.exclude.MatchString(text[start:end]) {
+		return false
+	}
+	return true
+}
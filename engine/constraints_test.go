@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotFollowedBy(t *testing.T) {
+	rules := []Rule{{
+		ID:            "secret",
+		Pattern:       `(?i)\b(SECRET|S)\b`,
+		NotFollowedBy: `(?i)\s*(SERVICE|SOCIETY|SYSTEM)`,
+		Severity:      "high",
+	}}
+
+	text := "The Secret Service arrived\nThis memo is SECRET\nsecret society, then SECRET again"
+	findings := Evaluate(text, "doc.txt", rules)
+
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %d: %+v", len(findings), findings)
+	}
+	if findings[0].Line != 2 || findings[1].Line != 3 {
+		t.Errorf("Expected findings on lines 2 and 3, got %d and %d", findings[0].Line, findings[1].Line)
+	}
+}
+
+func TestNotPrecededByAndExcludePattern(t *testing.T) {
+	rules := []Rule{
+		{ID: "portion-c", Pattern: `\(C\)`, NotPrecededBy: `Copyright\s*`, Severity: "medium"},
+		{ID: "key", Pattern: `AKIA[0-9A-Z]{16}`, ExcludePattern: `EXAMPLE`, Severity: "high"},
+	}
+
+	text := "Copyright (C) 2024\n(C) This paragraph is confidential\nAKIAIOSFODNN7EXAMPLE\nAKIAIOSFODNN7ABCDEFG"
+	findings := Evaluate(text, "doc.txt", rules)
+
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %d: %+v", len(findings), findings)
+	}
+	if findings[0].RuleID != "portion-c" || findings[0].Line != 2 {
+		t.Errorf("Unexpected first finding: %+v", findings[0])
+	}
+	if findings[1].RuleID != "key" || findings[1].Line != 4 {
+		t.Errorf("Unexpected second finding: %+v", findings[1])
+	}
+}
+
+func TestInvalidConstraintRejected(t *testing.T) {
+	_, err := CompileRules([]Rule{{ID: "bad", Pattern: "x", NotFollowedBy: "("}})
+	if err == nil {
+		t.Errorf("Expected error for invalid not_followed_by")
+	}
+}
+
+func TestConfigRulesCompile(t *testing.T) {
+	for _, path := range []string{"../config/rules.yaml", "../config/default.yaml"} {
+		rules, err := LoadRulesFromFile(path)
+		if err != nil {
+			t.Fatalf("LoadRulesFromFile(%s) failed: %v", path, err)
+		}
+		if _, err := CompileRules(rules); err != nil {
+			t.Errorf("CompileRules(%s) failed: %v", path, err)
+		}
+	}
+}
+
+func TestLookaroundIsBounded(t *testing.T) {
+	rules := []Rule{{
+		ID:            "token",
+		Pattern:       `tok\d`,
+		Scope:         ScopeDocument,
+		NotPrecededBy: `internal-.*`,
+		NotFollowedBy: `.*-test`,
+		Severity:      "high",
+	}}
+
+	// Context further than maxLookaround from the match is not seen.
+	pad := strings.Repeat("x", maxLookaround)
+	text := "internal-" + pad + "tok1\ninternal-tok2\ntok3-test\ntok4" + pad + "-test"
+	var got []string
+	for _, f := range Evaluate(text, "doc.txt", rules) {
+		got = append(got, f.Match)
+	}
+	if strings.Join(got, ",") != "tok1,tok4" {
+		t.Errorf("Expected tok1 and tok4, got %v", got)
+	}
+
+	// Each check costs the same however long the document is.
+	large := strings.Repeat("tok2 filler text internal-tok1\n", 1<<17)
+	start := time.Now()
+	findings := Evaluate(large, "doc.txt", rules)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Scanning %d bytes took %v", len(large), elapsed)
+	}
+	if len(findings) == 0 || findings[0].Match != "tok2" {
+		t.Errorf("Expected only tok2 matches, got %d findings starting %+v", len(findings), findings[:min(len(findings), 1)])
+	}
+}
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// DefaultContextChars is the number of characters of surrounding text
+// included on each side of a match in Finding.Context.
+const DefaultContextChars = 40
+
+var contextChars atomic.Int64
+
+func init() {
+	contextChars.Store(DefaultContextChars)
+}
+
+// SetContextChars sets how many characters before and after a match are
+// included in a finding's context. A negative value includes the full lines
+// the match spans.
+func SetContextChars(n int) {
+	contextChars.Store(int64(n))
+}
+
+// GetContextChars returns the current context window size.
+func GetContextChars() int {
+	return int(contextChars.Load())
+}
+
+// contextWindow returns the text around doc.slice(start, end), extending up
+// to n characters on each side without crossing the lines the match spans.
+func contextWindow(doc *document, start, end, startLine, endLine, n int) string {
+	lo := doc.lineStart(startLine)
+	hi := doc.lineEnd(endLine)
+	if n < 0 {
+		return doc.slice(lo, hi)
+	}
+	from := start
+	for i := 0; i < n && from > lo; i++ {
+		_, size := utf8.DecodeLastRuneInString(doc.slice(lo, from))
+		from -= size
+	}
+	to := end
+	for i := 0; i < n && to < hi; i++ {
+		_, size := utf8.DecodeRuneInString(doc.slice(to, hi))
+		to += size
+	}
+	return doc.slice(from, to)
+}
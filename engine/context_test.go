@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateReportsEveryMatch(t *testing.T) {
+	rules := []Rule{{ID: "ssn", Pattern: `\d{3}-\d{2}-\d{4}`, Severity: "high"}}
+	text := "header\nSSNs: 123-45-6789, 987-65-4321 and 111-22-3333"
+
+	findings := Evaluate(text, "doc.txt", rules)
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 findings, got %d", len(findings))
+	}
+
+	expected := []string{"123-45-6789", "987-65-4321", "111-22-3333"}
+	for i, f := range findings {
+		if f.Match != expected[i] {
+			t.Errorf("Finding %d: expected match %q, got %q", i, expected[i], f.Match)
+		}
+		if f.Line != 2 {
+			t.Errorf("Finding %d: expected line 2, got %d", i, f.Line)
+		}
+		if text[f.StartOffset:f.EndOffset] != f.Match {
+			t.Errorf("Finding %d: offsets %d-%d do not cover match", i, f.StartOffset, f.EndOffset)
+		}
+		lineText := strings.Split(text, "\n")[1]
+		if lineText[f.Column-1:f.EndColumn-1] != f.Match {
+			t.Errorf("Finding %d: columns %d-%d do not cover match", i, f.Column, f.EndColumn)
+		}
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	defer SetContextChars(DefaultContextChars)
+	SetContextChars(5)
+
+	line := strings.Repeat("a", 100) + "SECRET" + strings.Repeat("b", 100)
+	findings := Evaluate("first\n"+line, "min.js", []Rule{{ID: "s", Pattern: "SECRET"}})
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(findings))
+	}
+	if findings[0].Context != "aaaaaSECRETbbbbb" {
+		t.Errorf("Unexpected context %q", findings[0].Context)
+	}
+
+	findings = Evaluate("x SECRET y", "doc.txt", []Rule{{ID: "s", Pattern: "SECRET", ContextChars: -1}})
+	if findings[0].Context != "x SECRET y" {
+		t.Errorf("Expected full line context for per-rule override, got %q", findings[0].Context)
+	}
+}
+
+func TestContextWindowRespectsRuneBoundaries(t *testing.T) {
+	defer SetContextChars(DefaultContextChars)
+	SetContextChars(2)
+
+	findings := Evaluate("ééKEYéé", "doc.txt", []Rule{{ID: "k", Pattern: "KEY"}})
+	if len(findings) != 1 || findings[0].Context != "ééKEYéé" {
+		t.Errorf("Unexpected context: %+v", findings)
+	}
+}
@@ -0,0 +1,254 @@
+package engine
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RuleTypeDictionary matches a list of literal terms in a single pass.
+const RuleTypeDictionary = "dictionary"
+
+// DictionaryOptions configures a dictionary rule.
+type DictionaryOptions struct {
+	// Terms lists the literal terms to match.
+	Terms []string `json:"terms,omitempty" yaml:"terms"`
+	// File names a file with one term per line, relative to the rules file.
+	// Blank lines and lines starting with # are ignored.
+	File          string `json:"file,omitempty" yaml:"file"`
+	CaseSensitive bool   `json:"case_sensitive,omitempty" yaml:"case_sensitive"`
+	// WholeWord requires matches to be bounded by non-word characters.
+	WholeWord bool `json:"whole_word,omitempty" yaml:"whole_word"`
+}
+
+// resolveDictionaryFiles makes dictionary file references relative to the
+// directory of the rules file that declared them.
+func resolveDictionaryFiles(rules []Rule, rulesPath string) {
+	dir := filepath.Dir(rulesPath)
+	for i := range rules {
+		resolveDictionaryFile(&rules[i], dir)
+	}
+}
+
+// resolveDictionaryFile makes the rule's dictionary file reference relative
+// to dir.
+func resolveDictionaryFile(rule *Rule, dir string) {
+	d := rule.Dictionary
+	if d != nil && d.File != "" && !filepath.IsAbs(d.File) {
+		resolved := *d
+		resolved.File = filepath.Join(dir, d.File)
+		rule.Dictionary = &resolved
+	}
+}
+
+// readTermsFile reads one term per line from path.
+func readTermsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var terms []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		terms = append(terms, line)
+	}
+	return terms, scanner.Err()
+}
+
+// acNode is a state in the Aho-Corasick automaton.
+type acNode struct {
+	next map[rune]int32
+	fail int32
+	// term is the index of the term ending at this node, or -1.
+	term int32
+	// dict is the nearest node along the fail chain that ends a term, or -1.
+	dict int32
+}
+
+// dictionaryMatcher finds dictionary terms with an Aho-Corasick automaton.
+type dictionaryMatcher struct {
+	terms     []string
+	termRunes []int
+	nodes     []acNode
+	fold      bool
+	wholeWord bool
+	maxRunes  int
+}
+
+// newDictionaryMatcher loads the terms of a dictionary rule and builds the automaton.
+func newDictionaryMatcher(rule Rule) (*dictionaryMatcher, error) {
+	if rule.Dictionary == nil {
+		return nil, fmt.Errorf("dictionary rule requires a dictionary block")
+	}
+	opts := rule.Dictionary
+	terms := append([]string(nil), opts.Terms...)
+	if opts.File != "" {
+		fileTerms, err := readTermsFile(opts.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dictionary file: %w", err)
+		}
+		terms = append(terms, fileTerms...)
+	}
+
+	m := &dictionaryMatcher{
+		nodes:     []acNode{{next: map[rune]int32{}, term: -1, dict: -1}},
+		fold:      !opts.CaseSensitive,
+		wholeWord: opts.WholeWord,
+	}
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		key := m.normalize(term)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		m.insert(key, term)
+	}
+	if len(m.terms) == 0 {
+		return nil, fmt.Errorf("dictionary rule has no terms")
+	}
+	m.build()
+	return m, nil
+}
+
+func (m *dictionaryMatcher) normalize(term string) string {
+	if m.fold {
+		return strings.Map(unicode.ToLower, term)
+	}
+	return term
+}
+
+func (m *dictionaryMatcher) insert(key, term string) {
+	node := int32(0)
+	runes := 0
+	for _, r := range key {
+		next, ok := m.nodes[node].next[r]
+		if !ok {
+			next = int32(len(m.nodes))
+			m.nodes = append(m.nodes, acNode{next: map[rune]int32{}, term: -1, dict: -1})
+			m.nodes[node].next[r] = next
+		}
+		node = next
+		runes++
+	}
+	m.nodes[node].term = int32(len(m.terms))
+	m.terms = append(m.terms, term)
+	m.termRunes = append(m.termRunes, runes)
+	if runes > m.maxRunes {
+		m.maxRunes = runes
+	}
+}
+
+// build computes fail and dictionary links breadth-first.
+func (m *dictionaryMatcher) build() {
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[node].next {
+			fail := m.nodes[node].fail
+			for {
+				if next, ok := m.nodes[fail].next[r]; ok && next != child {
+					m.nodes[child].fail = next
+					break
+				}
+				if fail == 0 {
+					m.nodes[child].fail = 0
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			f := m.nodes[child].fail
+			if m.nodes[f].term >= 0 {
+				m.nodes[child].dict = f
+			} else {
+				m.nodes[child].dict = m.nodes[f].dict
+			}
+			queue = append(queue, child)
+		}
+	}
+}
+
+// FindAllStringIndex returns [start, end, term] for every dictionary term
+// found in s, ordered by position. Overlapping terms are all reported.
+func (m *dictionaryMatcher) FindAllStringIndex(s string, n int) [][]int {
+	var locs [][]int
+	// starts is a ring buffer of the byte offsets of the last maxRunes runes.
+	starts := make([]int, m.maxRunes)
+	node := int32(0)
+	count := 0
+	for i, r := range s {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		end := i + size
+		starts[count%m.maxRunes] = i
+		count++
+		if m.fold {
+			r = unicode.ToLower(r)
+		}
+		for {
+			if next, ok := m.nodes[node].next[r]; ok {
+				node = next
+				break
+			}
+			if node == 0 {
+				break
+			}
+			node = m.nodes[node].fail
+		}
+		for out := node; out > 0; out = m.nodes[out].dict {
+			term := m.nodes[out].term
+			if term < 0 {
+				continue
+			}
+			start := starts[(count-m.termRunes[term])%m.maxRunes]
+			if m.wholeWord && !isWordBoundary(s, start, end) {
+				continue
+			}
+			locs = append(locs, []int{start, end, int(term)})
+		}
+	}
+	sort.SliceStable(locs, func(a, b int) bool {
+		if locs[a][0] != locs[b][0] {
+			return locs[a][0] < locs[b][0]
+		}
+		return locs[a][1] > locs[b][1]
+	})
+	if n >= 0 && len(locs) > n {
+		locs = locs[:n]
+	}
+	return locs
+}
+
+// isWordBoundary reports whether s[start:end] is not adjacent to word characters.
+func isWordBoundary(s string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:start])
+		if isWordRune(r) {
+			return false
+		}
+	}
+	if end < len(s) {
+		r, _ := utf8.DecodeRuneInString(s[end:])
+		if isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDictionaryRule(t *testing.T) {
+	rules := []Rule{{
+		ID:       "codewords",
+		Type:     RuleTypeDictionary,
+		Severity: "high",
+		Dictionary: &DictionaryOptions{
+			Terms:     []string{"Project Orion", "ORION", "Nightfall", "Straße"},
+			WholeWord: true,
+		},
+	}}
+
+	text := "Status of project orion is green\nOrionids meteor shower\nNIGHTFALL approved\nHauptstraße and STRASSE"
+	findings := Evaluate(text, "memo.txt", rules)
+
+	expected := []struct {
+		line  int
+		match string
+		term  string
+	}{
+		{1, "project orion", "Project Orion"},
+		{1, "orion", "ORION"},
+		{3, "NIGHTFALL", "Nightfall"},
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d findings, got %d: %+v", len(expected), len(findings), findings)
+	}
+	for i, e := range expected {
+		f := findings[i]
+		if f.Line != e.line || f.Match != e.match || f.Term != e.term {
+			t.Errorf("Finding %d: expected %+v, got line=%d match=%q term=%q", i, e, f.Line, f.Match, f.Term)
+		}
+	}
+}
+
+func TestDictionaryRuleCaseSensitiveAndUnicode(t *testing.T) {
+	rules := []Rule{{
+		ID:         "terms",
+		Type:       RuleTypeDictionary,
+		Dictionary: &DictionaryOptions{Terms: []string{"Ünïcode", "he", "she", "hers"}, CaseSensitive: true},
+	}}
+
+	findings := Evaluate("ÜNÏCODE Ünïcode ushers", "doc.txt", rules)
+
+	var matches []string
+	for _, f := range findings {
+		matches = append(matches, f.Match)
+	}
+	want := []string{"Ünïcode", "she", "hers", "he"}
+	if len(matches) != len(want) {
+		t.Fatalf("Expected matches %v, got %v", want, matches)
+	}
+	for i := range want {
+		if matches[i] != want[i] {
+			t.Errorf("Expected matches %v, got %v", want, matches)
+			break
+		}
+	}
+}
+
+func TestDictionaryRuleFromFile(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "terms.txt"), []byte("# codewords\nBLUEBIRD\n\nREDWING\n"), 0644); err != nil {
+		t.Fatalf("Failed to write terms file: %v", err)
+	}
+	rulesFile := filepath.Join(tempDir, "rules.yaml")
+	yamlContent := `rules:
+  - id: codewords
+    type: dictionary
+    severity: high
+    dictionary:
+      file: terms.txt
+      whole_word: true
+`
+	if err := os.WriteFile(rulesFile, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to write rules file: %v", err)
+	}
+
+	rules, err := LoadRulesFromFile(rulesFile)
+	if err != nil {
+		t.Fatalf("LoadRulesFromFile failed: %v", err)
+	}
+	set, err := CompileRules(rules)
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+
+	findings := set.Evaluate("operation redwing begins", "doc.txt")
+	if len(findings) != 1 || findings[0].Term != "REDWING" {
+		t.Errorf("Expected REDWING finding, got %+v", findings)
+	}
+}
+
+func TestDictionaryRuleRequiresTerms(t *testing.T) {
+	rules := []Rule{
+		{ID: "missing", Type: RuleTypeDictionary},
+		{ID: "empty", Type: RuleTypeDictionary, Dictionary: &DictionaryOptions{}},
+		{ID: "nofile", Type: RuleTypeDictionary, Dictionary: &DictionaryOptions{File: "does-not-exist.txt"}},
+	}
+	for _, rule := range rules {
+		if _, err := CompileRules([]Rule{rule}); err == nil {
+			t.Errorf("Expected error for rule %s", rule.ID)
+		}
+	}
+}
@@ -1,9 +1,10 @@
 package engine
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
-	"regexp"
-	"strings"
+	"sync/atomic"
 
 	"gopkg.in/yaml.v3"
 
@@ -12,100 +13,290 @@ import (
 
 // Rule defines a pattern that will be searched in text.
 type Rule struct {
-	ID       string `json:"id"`
-	Pattern  string `json:"pattern"`
-	Severity string `json:"severity"`
-	Description string `json:"description"`
+	ID          string   `json:"id" yaml:"id"`
+	Name        string   `json:"name,omitempty" yaml:"name"`
+	Type        string   `json:"type,omitempty" yaml:"type"`
+	Pattern     string   `json:"pattern" yaml:"pattern"`
+	Severity    string   `json:"severity" yaml:"severity"`
+	Description string   `json:"description" yaml:"description"`
+	Category    string   `json:"category,omitempty" yaml:"category"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags"`
+
+	// NotFollowedBy, NotPrecededBy and ExcludePattern replace the lookaround
+	// assertions that RE2 does not support. A match is dropped when the text
+	// immediately after it matches NotFollowedBy, the text immediately before
+	// it matches NotPrecededBy, or the matched text itself matches
+	// ExcludePattern.
+	NotFollowedBy  string `json:"not_followed_by,omitempty" yaml:"not_followed_by"`
+	NotPrecededBy  string `json:"not_preceded_by,omitempty" yaml:"not_preceded_by"`
+	ExcludePattern string `json:"exclude_pattern,omitempty" yaml:"exclude_pattern"`
+
+	// Scope selects the text a pattern is matched against: a single line
+	// (the default), a sliding window of Window lines, a paragraph, or the
+	// whole document.
+	Scope  string `json:"scope,omitempty" yaml:"scope"`
+	Window int    `json:"window,omitempty" yaml:"window"`
+
+	// ContextChars overrides the number of characters of surrounding text
+	// reported on each side of a match. Zero uses the engine default.
+	ContextChars int `json:"context_chars,omitempty" yaml:"context_chars"`
+
+	// Validator names a built-in checker (luhn, ssn, iban, aba, aws_key)
+	// run on each match; matches that fail validation are dropped.
+	Validator string `json:"validator,omitempty" yaml:"validator"`
+
+	// Entropy configures rules of type "entropy".
+	Entropy *EntropyOptions `json:"entropy,omitempty" yaml:"entropy"`
+
+	// Dictionary configures rules of type "dictionary".
+	Dictionary *DictionaryOptions `json:"dictionary,omitempty" yaml:"dictionary"`
+
+	// Composite configures rules of type "composite".
+	Composite *CompositeOptions `json:"composite,omitempty" yaml:"composite"`
+
+	// MaxMatches caps the matches of this rule reported for one document,
+	// overriding the global per-rule cap. Zero uses the global cap.
+	MaxMatches int `json:"max_matches,omitempty" yaml:"max_matches"`
+
+	// Allowlist suppresses known false positives for this rule only.
+	Allowlist *Allowlist `json:"allowlist,omitempty" yaml:"allowlist"`
+
+	// Redact selects how this rule's matches are masked by redaction,
+	// overriding the ruleset's choice.
+	Redact *RedactOptions `json:"redact,omitempty" yaml:"redact"`
+
+	// Examples are texts the rule must and must not match.
+	Examples *RuleExamples `json:"examples,omitempty" yaml:"examples"`
 }
 
+// RuleTypeRegex is the default rule type, matching Pattern as an RE2 regex.
+const RuleTypeRegex = "regex"
+
 // RulesConfig represents the YAML structure for rules configuration
 type RulesConfig struct {
-	Rules []Rule `json:"rules" yaml:"rules"`
+	Name        string `json:"name,omitempty" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description"`
+	Version     string `json:"version,omitempty" yaml:"version"`
+	Rules       []Rule `json:"rules" yaml:"rules"`
+
+	// Allowlist suppresses known false positives for every rule in the set.
+	Allowlist *Allowlist `json:"allowlist,omitempty" yaml:"allowlist"`
+	// Exclusions is a list of regexes treated as global allowlist patterns.
+	Exclusions []string `json:"exclusions,omitempty" yaml:"exclusions"`
+	// DisableInlineSuppressions ignores dws:ignore directives in scanned
+	// text, for rule sets where document authors must not opt out of scans.
+	DisableInlineSuppressions bool `json:"disable_inline_suppressions,omitempty" yaml:"disable_inline_suppressions"`
+
+	// Extends and Include name other rules files, relative to this one,
+	// whose rules and allowlists are inherited. Overrides and Disable adjust
+	// the inherited rules, and rules defined here replace inherited rules
+	// with the same ID.
+	Extends   string         `json:"extends,omitempty" yaml:"extends"`
+	Include   []string       `json:"include,omitempty" yaml:"include"`
+	Overrides []RuleOverride `json:"overrides,omitempty" yaml:"overrides"`
+	Disable   []string       `json:"disable,omitempty" yaml:"disable"`
+
+	// Policy decides each document's verdict from its findings. Without one
+	// any finding earns a warning.
+	Policy *Policy `json:"policy,omitempty" yaml:"policy"`
+
+	// Redact selects how matches are masked by redaction for rules that do
+	// not choose their own strategy.
+	Redact *RedactOptions `json:"redact,omitempty" yaml:"redact"`
+}
+
+// allowlist returns the ruleset-wide allowlist with Exclusions merged in.
+func (c *RulesConfig) allowlist() *Allowlist {
+	if len(c.Exclusions) == 0 {
+		return c.Allowlist
+	}
+	merged := Allowlist{}
+	if c.Allowlist != nil {
+		merged = *c.Allowlist
+	}
+	merged.Patterns = append(append([]string(nil), merged.Patterns...), c.Exclusions...)
+	return &merged
 }
 
 // Finding represents a rule match inside a document.
 type Finding struct {
-	FileID      string `json:"file_id"`
-	RuleID      string `json:"rule_id"`
-	Severity    string `json:"severity"`
-	Line        int    `json:"line"`
-	Context     string `json:"context"`
-	Description string `json:"description"`
+	FileID      string   `json:"file_id"`
+	RuleID      string   `json:"rule_id"`
+	Severity    string   `json:"severity"`
+	Line        int      `json:"line"`
+	Page        int      `json:"page,omitempty"` // 1-based, in paged documents such as PDFs
+	Context     string   `json:"context"`
+	Description string   `json:"description"`
+	Category    string   `json:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Match       string   `json:"match"`
+	EndLine     int      `json:"end_line"`
+	Column      int      `json:"column"`
+	EndColumn   int      `json:"end_column"`
+	StartOffset int      `json:"start_offset"`
+	EndOffset   int      `json:"end_offset"`
+	Validator   string   `json:"validator,omitempty"`
+	Validated   bool     `json:"validated,omitempty"`
+	Term        string   `json:"term,omitempty"`
+	// SuppressedBy records what dropped a suppressed finding, e.g.
+	// "rule:value", "global:path" or "inline:ignore-next-line".
+	SuppressedBy string `json:"suppressed_by,omitempty"`
+	// Contributing lists the findings that triggered a composite rule.
+	Contributing []Finding `json:"contributing,omitempty"`
 }
 
-var currentRules []Rule
+// normalize fills in defaults for fields that older rule files omit.
+// Rules that only carry a name use it as their ID, and severities are
+// rewritten to their canonical names.
+func (r *Rule) normalize() {
+	if r.ID == "" {
+		r.ID = r.Name
+	}
+	if r.Type == "" {
+		r.Type = RuleTypeRegex
+	}
+	if r.Scope == "" {
+		r.Scope = ScopeLine
+	}
+	r.Severity = normalizeSeverity(r.Severity)
+}
+
+var currentRuleSet atomic.Pointer[CompiledRuleSet]
 var debugMode bool
 
-// SetRules replaces the in-memory rule set.
-func SetRules(rules []Rule) {
-	currentRules = rules
+// SetRules compiles the rules and atomically replaces the in-memory rule set.
+// The current rule set is left untouched if any rule fails to compile.
+func SetRules(rules []Rule) error {
+	set, err := CompileRules(rules)
+	if err != nil {
+		return err
+	}
+	SetRuleSet(set)
+	return nil
+}
+
+// SetRulesConfig compiles the rules and allowlist of a configuration and
+// atomically replaces the in-memory rule set.
+func SetRulesConfig(config *RulesConfig) error {
+	set, err := CompileRulesConfig(config)
+	if err != nil {
+		return err
+	}
+	SetRuleSet(set)
+	return nil
+}
+
+// SetRuleSet atomically replaces the in-memory rule set.
+func SetRuleSet(set *CompiledRuleSet) {
+	currentRuleSet.Store(set)
+}
+
+// GetRuleSet returns the current compiled rule set. Callers should fetch it
+// once per scan so that a concurrent reload does not change rules mid-scan.
+func GetRuleSet() *CompiledRuleSet {
+	return currentRuleSet.Load()
 }
 
 // LoadRulesFromFile loads rules from a YAML file without setting them globally.
 func LoadRulesFromFile(path string) ([]Rule, error) {
+	config, err := LoadRulesConfig(path)
+	if err != nil {
+		return []Rule{}, err
+	}
+	return config.Rules, nil
+}
+
+// LoadRuleSet loads and compiles a rules file without setting it globally.
+func LoadRuleSet(path string) (*CompiledRuleSet, error) {
+	config, err := LoadRulesConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return CompileRulesConfig(config)
+}
+
+// LoadRulesConfig loads a rules file, including its ruleset metadata, and
+// resolves the rules files it extends or includes.
+func LoadRulesConfig(path string) (*RulesConfig, error) {
+	return loadRulesConfig(path, nil)
+}
+
+// readRulesConfig reads and parses a single rules file.
+func readRulesConfig(path string) (*RulesConfig, error) {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"file":  path,
 			"error": err,
 		}).Error("Failed to read rules file")
-		return []Rule{}, err
+		return nil, err
 	}
-	var config RulesConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	config, err := ParseRulesConfig(data)
+	if err != nil {
 		logrus.WithFields(logrus.Fields{
-			"file":     path,
-			"error":    err,
+			"file":      path,
+			"error":     err,
 			"yaml_data": string(data),
 		}).Error("Failed to unmarshal YAML rules file")
-		return []Rule{}, err
+		return nil, fmt.Errorf("rules file %s: %w", path, err)
 	}
-	return config.Rules, nil
+	resolveDictionaryFiles(config.Rules, path)
+	return config, nil
+}
+
+// ParseRulesConfig parses rules YAML that does not come from a file. Extends
+// and include references are left unresolved.
+func ParseRulesConfig(data []byte) (*RulesConfig, error) {
+	var config RulesConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	for i := range config.Rules {
+		config.Rules[i].normalize()
+	}
+	return &config, nil
 }
 
-// GetRules returns the current in-memory rule set.
+// GetRules returns the rules in the current in-memory rule set.
 func GetRules() []Rule {
-	return currentRules
+	return GetRuleSet().Rules()
 }
 
-// Evaluate scans the provided text and returns findings for the current rules.
+// Evaluate scans the provided text and returns findings for the given rules.
+// Each pattern is compiled once per call; rules with invalid patterns are
+// logged and skipped. Prefer CompiledRuleSet.Evaluate for repeated scans.
 func Evaluate(text, fileID string, rules []Rule) []Finding {
-	var findings []Finding
-	lines := strings.Split(text, "\n")
-	for i, line := range lines {
-		for _, rule := range rules {
-			re, err := regexp.Compile(rule.Pattern)
-			if err != nil {
-				logrus.WithFields(logrus.Fields{
-					"rule_id":  rule.ID,
-					"pattern":  rule.Pattern,
-					"error":    err,
-				}).Warn("Failed to compile regex for rule")
-				continue
-			}
-			if re.MatchString(line) {
-				findings = append(findings, Finding{
-					FileID:      fileID,
-					RuleID:      rule.ID,
-					Severity:    rule.Severity,
-					Line:        i + 1,
-					Context:     line,
-					Description: rule.Description,
-				})
-			}
-		}
-	}
-	return findings
+	set, errs := compileRules(rules)
+	logRuleErrors(errs)
+	return set.Evaluate(text, fileID)
+}
+
+// EvaluateContext is Evaluate with a context. It returns the context's error
+// if the scan is cancelled or its deadline passes.
+func EvaluateContext(ctx context.Context, text, fileID string, rules []Rule) ([]Finding, error) {
+	set, errs := compileRules(rules)
+	logRuleErrors(errs)
+	result, err := set.ScanContext(ctx, text, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return result.Findings, nil
 }
 
 // LoadRulesFromYAML loads rules from a YAML file and sets them globally.
 // This is called during initialization.
 func LoadRulesFromYAML(path string) error {
-	rules, err := LoadRulesFromFile(path)
+	config, err := LoadRulesConfig(path)
 	if err != nil {
 		return err
 	}
-	SetRules(rules)
+	if err := SetRulesConfig(config); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"file":  path,
+			"error": err,
+		}).Error("Failed to compile rules file")
+		return err
+	}
 	return nil
 }
 
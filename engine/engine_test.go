@@ -208,3 +208,57 @@ func TestSetAndGetDebugMode(t *testing.T) {
 		t.Errorf("Expected debug mode to be false")
 	}
 }
+
+func TestLoadRulesConfigExtendedSchema(t *testing.T) {
+	tempDir := t.TempDir()
+	yamlFile := filepath.Join(tempDir, "extended.yaml")
+
+	yamlContent := `name: "Classification Detection"
+description: "Classification markings"
+version: "1.0"
+rules:
+  - name: "NOFORN_MARKING"
+    description: "Detects NOFORN markings"
+    type: "regex"
+    pattern: "\\bNOFORN\\b"
+    severity: "HIGH"
+    category: "DISSEMINATION_CONTROL"
+    tags: ["noforn", "dissemination-control"]
+`
+	if err := os.WriteFile(yamlFile, []byte(yamlContent), 0644); err != nil {
+		t.Fatalf("Failed to create test YAML file: %v", err)
+	}
+
+	config, err := LoadRulesConfig(yamlFile)
+	if err != nil {
+		t.Fatalf("LoadRulesConfig failed: %v", err)
+	}
+	if config.Name != "Classification Detection" || config.Version != "1.0" {
+		t.Errorf("Unexpected ruleset metadata: %q %q", config.Name, config.Version)
+	}
+	if len(config.Rules) != 1 {
+		t.Fatalf("Expected 1 rule, got %d", len(config.Rules))
+	}
+
+	rule := config.Rules[0]
+	if rule.ID != "NOFORN_MARKING" {
+		t.Errorf("Expected ID to default to name, got '%s'", rule.ID)
+	}
+	if rule.Category != "DISSEMINATION_CONTROL" || len(rule.Tags) != 2 {
+		t.Errorf("Expected category and tags to be loaded, got %q %v", rule.Category, rule.Tags)
+	}
+
+	findings := Evaluate("SECRET//NOFORN", "doc.txt", config.Rules)
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(findings))
+	}
+	if findings[0].Category != "DISSEMINATION_CONTROL" || len(findings[0].Tags) != 2 {
+		t.Errorf("Expected category and tags on finding, got %q %v", findings[0].Category, findings[0].Tags)
+	}
+}
+
+func TestCompileRulesUnsupportedType(t *testing.T) {
+	if _, err := CompileRules([]Rule{{ID: "x", Type: "unknown", Pattern: "x"}}); err == nil {
+		t.Errorf("Expected error for unsupported rule type")
+	}
+}
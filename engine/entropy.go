@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// RuleTypeEntropy flags random-looking tokens such as API keys that do not
+// follow a fixed pattern.
+const RuleTypeEntropy = "entropy"
+
+// EntropyOptions configures an entropy rule.
+type EntropyOptions struct {
+	// Charset is "base64" (the default) or "hex". Tokens are maximal runs of
+	// characters from the charset.
+	Charset string `json:"charset,omitempty" yaml:"charset"`
+	// Threshold is the minimum Shannon entropy in bits per character.
+	Threshold float64 `json:"threshold,omitempty" yaml:"threshold"`
+	MinLength int     `json:"min_length,omitempty" yaml:"min_length"`
+	MaxLength int     `json:"max_length,omitempty" yaml:"max_length"`
+	// Keyword, when set, is a regex that must match before the token, within
+	// KeywordDistance characters (or anywhere earlier in the scope if zero).
+	Keyword         string `json:"keyword,omitempty" yaml:"keyword"`
+	KeywordDistance int    `json:"keyword_distance,omitempty" yaml:"keyword_distance"`
+}
+
+// Entropy defaults per charset. Random base64 approaches 6 bits per
+// character and random hex 4, while English words sit well below both. A
+// token of n characters has at most log2(n) bits per character, so the
+// minimum lengths are long enough to reach the thresholds.
+const (
+	defaultBase64Threshold = 4.5
+	defaultBase64MinLen    = 23
+	defaultHexThreshold    = 3.0
+	defaultHexMinLen       = 20
+)
+
+// entropyMatcher finds high-entropy tokens in text.
+type entropyMatcher struct {
+	inCharset func(byte) bool
+	threshold float64
+	minLen    int
+	maxLen    int
+	keyword   *regexp.Regexp
+	distance  int
+}
+
+func isBase64Char(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+		c == '+' || c == '/' || c == '=' || c == '-' || c == '_'
+}
+
+func isHexChar(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// newEntropyMatcher validates the options of an entropy rule and applies defaults.
+func newEntropyMatcher(rule Rule) (*entropyMatcher, error) {
+	opts := EntropyOptions{}
+	if rule.Entropy != nil {
+		opts = *rule.Entropy
+	}
+	m := &entropyMatcher{
+		threshold: opts.Threshold,
+		minLen:    opts.MinLength,
+		maxLen:    opts.MaxLength,
+		distance:  opts.KeywordDistance,
+	}
+	switch opts.Charset {
+	case "", "base64":
+		m.inCharset = isBase64Char
+		if m.threshold == 0 {
+			m.threshold = defaultBase64Threshold
+		}
+		if m.minLen == 0 {
+			m.minLen = defaultBase64MinLen
+		}
+	case "hex":
+		m.inCharset = isHexChar
+		if m.threshold == 0 {
+			m.threshold = defaultHexThreshold
+		}
+		if m.minLen == 0 {
+			m.minLen = defaultHexMinLen
+		}
+	default:
+		return nil, fmt.Errorf("unsupported entropy charset %q", opts.Charset)
+	}
+	if m.maxLen != 0 && m.maxLen < m.minLen {
+		return nil, fmt.Errorf("entropy max_length %d is less than min_length %d", m.maxLen, m.minLen)
+	}
+	if opts.Keyword != "" {
+		re, err := regexp.Compile(opts.Keyword)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entropy keyword: %w", err)
+		}
+		m.keyword = re
+	}
+	return m, nil
+}
+
+// FindAllStringIndex returns the offsets of tokens in s whose length and
+// entropy fall within the configured bounds.
+func (m *entropyMatcher) FindAllStringIndex(s string, n int) [][]int {
+	var locs [][]int
+	for i := 0; i < len(s); {
+		if !m.inCharset(s[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(s) && m.inCharset(s[i]) {
+			i++
+		}
+		if m.accepts(s, start, i) {
+			locs = append(locs, []int{start, i})
+			if len(locs) == n {
+				break
+			}
+		}
+	}
+	return locs
+}
+
+func (m *entropyMatcher) accepts(s string, start, end int) bool {
+	length := end - start
+	if length < m.minLen || (m.maxLen > 0 && length > m.maxLen) {
+		return false
+	}
+	if shannonEntropy(s[start:end]) < m.threshold {
+		return false
+	}
+	if m.keyword == nil {
+		return true
+	}
+	from := 0
+	if m.distance > 0 && start > m.distance {
+		from = start - m.distance
+	}
+	return m.keyword.MatchString(s[from:start])
+}
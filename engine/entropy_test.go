@@ -0,0 +1,70 @@
+package engine
+
+import "testing"
+
+func TestEntropyRule(t *testing.T) {
+	rules := []Rule{{
+		ID:       "high-entropy",
+		Type:     RuleTypeEntropy,
+		Severity: "high",
+		Entropy:  &EntropyOptions{MinLength: 20},
+	}}
+
+	text := "token = 9fJ2kLq8ZxP0vRt7WmYc3BnA5sDe\nthe quick brown fox jumps over the lazy dog\naaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	findings := Evaluate(text, "config.env", rules)
+
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d: %+v", len(findings), findings)
+	}
+	if findings[0].Match != "9fJ2kLq8ZxP0vRt7WmYc3BnA5sDe" || findings[0].Line != 1 {
+		t.Errorf("Unexpected finding: %+v", findings[0])
+	}
+}
+
+func TestEntropyRuleDefaultMinLength(t *testing.T) {
+	rules := []Rule{{ID: "high-entropy", Type: RuleTypeEntropy, Severity: "high"}}
+
+	// Tokens of distinct characters have the most entropy their length
+	// allows: log2(23) reaches the base64 threshold and log2(22) does not.
+	text := "a ABCDEFGHIJKLMNOPQRSTUVW\nb ABCDEFGHIJKLMNOPQRSTUV"
+	findings := Evaluate(text, "config.env", rules)
+	if len(findings) != 1 || findings[0].Match != "ABCDEFGHIJKLMNOPQRSTUVW" {
+		t.Fatalf("Expected only the %d-character token, got %+v", defaultBase64MinLen, findings)
+	}
+}
+
+func TestEntropyRuleHexAndKeyword(t *testing.T) {
+	rules := []Rule{{
+		ID:       "hex-secret",
+		Type:     RuleTypeEntropy,
+		Severity: "high",
+		Entropy: &EntropyOptions{
+			Charset:         "hex",
+			MinLength:       32,
+			MaxLength:       64,
+			Keyword:         `(?i)(key|token|secret)`,
+			KeywordDistance: 20,
+		},
+	}}
+
+	text := "api_secret: 4f9a2c71e8b35d06a1f7c2e94b80d3a6\nchecksum: 4f9a2c71e8b35d06a1f7c2e94b80d3a6"
+	findings := Evaluate(text, "config.yaml", rules)
+
+	if len(findings) != 1 || findings[0].Line != 1 {
+		t.Fatalf("Expected 1 finding on line 1, got %+v", findings)
+	}
+}
+
+func TestEntropyRuleInvalidOptions(t *testing.T) {
+	testCases := []EntropyOptions{
+		{Charset: "base32"},
+		{MinLength: 30, MaxLength: 10},
+		{Keyword: "("},
+	}
+	for _, opts := range testCases {
+		opts := opts
+		if _, err := CompileRules([]Rule{{ID: "e", Type: RuleTypeEntropy, Entropy: &opts}}); err == nil {
+			t.Errorf("Expected error for options %+v", opts)
+		}
+	}
+}
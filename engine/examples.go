@@ -0,0 +1,103 @@
+package engine
+
+import "fmt"
+
+// RuleExamples are sample texts a rule must and must not match. They act as
+// unit tests for the rule and are checked by "dws rules test".
+type RuleExamples struct {
+	Match   []string `json:"match,omitempty" yaml:"match"`
+	NoMatch []string `json:"no_match,omitempty" yaml:"no_match"`
+}
+
+// ExampleResult is the outcome of checking one rule example.
+type ExampleResult struct {
+	RuleID  string `json:"rule_id"`
+	Example string `json:"example"`
+	// Expect is "match" or "no_match".
+	Expect  string `json:"expect"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// ExampleReport summarizes the example checks for a rule set.
+type ExampleReport struct {
+	Passed  int             `json:"passed"`
+	Failed  int             `json:"failed"`
+	Results []ExampleResult `json:"results"`
+	// Untested lists the rules that declare no examples.
+	Untested []string `json:"untested,omitempty"`
+}
+
+// TestExamples scans every example of every rule with the whole set, so
+// allowlists and composite rules behave as they would in a real scan, and
+// checks whether the rule reported a finding.
+func (s *CompiledRuleSet) TestExamples() *ExampleReport {
+	report := &ExampleReport{Results: []ExampleResult{}}
+	if s == nil {
+		return report
+	}
+	for _, rule := range s.rules {
+		if !s.has(rule.ID) {
+			continue
+		}
+		if rule.Examples == nil || len(rule.Examples.Match)+len(rule.Examples.NoMatch) == 0 {
+			report.Untested = append(report.Untested, rule.ID)
+			continue
+		}
+		for _, text := range rule.Examples.Match {
+			report.add(s.testExample(rule.ID, text, true))
+		}
+		for _, text := range rule.Examples.NoMatch {
+			report.add(s.testExample(rule.ID, text, false))
+		}
+	}
+	return report
+}
+
+func (s *CompiledRuleSet) testExample(ruleID, text string, wantMatch bool) ExampleResult {
+	result := ExampleResult{RuleID: ruleID, Example: text, Expect: "no_match"}
+	if wantMatch {
+		result.Expect = "match"
+	}
+	var match *Finding
+	findings := s.Evaluate(text, "example")
+	for i := range findings {
+		if findings[i].RuleID == ruleID {
+			match = &findings[i]
+			break
+		}
+	}
+	switch {
+	case wantMatch && match == nil:
+		result.Message = "expected a match, found none"
+	case !wantMatch && match != nil:
+		result.Message = fmt.Sprintf("expected no match, matched %q on line %d", match.Match, match.Line)
+	default:
+		result.Passed = true
+	}
+	return result
+}
+
+func (r *ExampleReport) add(result ExampleResult) {
+	if result.Passed {
+		r.Passed++
+	} else {
+		r.Failed++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// has reports whether the rule with the given ID compiled into the set.
+func (s *CompiledRuleSet) has(id string) bool {
+	for _, cr := range s.compiled {
+		if cr.rule.ID == id {
+			return true
+		}
+	}
+	for _, cr := range s.composites {
+		if cr.rule.ID == id {
+			return true
+		}
+	}
+	return false
+}
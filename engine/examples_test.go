@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"os"
+	"testing"
+)
+
+func TestExamples(t *testing.T) {
+	set, err := CompileRulesConfig(&RulesConfig{
+		Rules: []Rule{
+			{ID: "secret", Pattern: `(?i)\bsecret\b`, Examples: &RuleExamples{
+				Match:   []string{"top secret plan", "SECRET", "secrets"},
+				NoMatch: []string{"secretary", "secret sauce", "nothing here"},
+			}},
+			{ID: "loose", Pattern: `secret`, Examples: &RuleExamples{
+				NoMatch: []string{"secretary"},
+			}},
+			{ID: "untested", Pattern: `x`},
+			{ID: "combo", Type: RuleTypeComposite, Composite: &CompositeOptions{All: []string{"secret", "untested"}}, Examples: &RuleExamples{
+				Match: []string{"secret x"},
+			}},
+		},
+		Allowlist: &Allowlist{Patterns: []string{"secret sauce"}},
+	})
+	if err != nil {
+		t.Fatalf("CompileRulesConfig failed: %v", err)
+	}
+	report := set.TestExamples()
+
+	if report.Passed != 6 || report.Failed != 2 {
+		t.Errorf("Expected 6 passed and 2 failed, got %d and %d: %+v", report.Passed, report.Failed, report.Results)
+	}
+	failed := map[string]string{}
+	for _, r := range report.Results {
+		if !r.Passed {
+			failed[r.RuleID+"/"+r.Example] = r.Message
+		}
+	}
+	want := map[string]string{
+		"secret/secrets":  "expected a match, found none",
+		"loose/secretary": `expected no match, matched "secret" on line 1`,
+	}
+	for key, msg := range want {
+		if got := failed[key]; got != msg {
+			t.Errorf("%s: expected failure %q, got %q", key, msg, got)
+		}
+	}
+	if len(report.Untested) != 1 || report.Untested[0] != "untested" {
+		t.Errorf("Expected untested rule to be listed, got %v", report.Untested)
+	}
+}
+
+func TestConfigRuleExamples(t *testing.T) {
+	for _, path := range []string{"../config/rules.yaml", "../config/default.yaml"} {
+		set, err := LoadRuleSet(path)
+		if err != nil {
+			t.Fatalf("LoadRuleSet(%s) failed: %v", path, err)
+		}
+		report := set.TestExamples()
+		if report.Passed == 0 {
+			t.Errorf("Expected %s to have rule examples", path)
+		}
+		for _, r := range report.Results {
+			if !r.Passed {
+				t.Errorf("%s: rule %s: %s: %q", path, r.RuleID, r.Message, r.Example)
+			}
+		}
+	}
+}
+
+func TestParseRulesConfigExamples(t *testing.T) {
+	data, err := os.ReadFile("../config/rules.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read rules: %v", err)
+	}
+	config, err := ParseRulesConfig(data)
+	if err != nil {
+		t.Fatalf("ParseRulesConfig failed: %v", err)
+	}
+	for _, r := range config.Rules {
+		if r.ID == "SECRET_DETECTION" {
+			if r.Examples == nil || len(r.Examples.Match) == 0 || len(r.Examples.NoMatch) == 0 {
+				t.Errorf("Expected SECRET_DETECTION examples, got %+v", r.Examples)
+			}
+			return
+		}
+	}
+	t.Error("SECRET_DETECTION not found")
+}
@@ -0,0 +1,143 @@
+package engine
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// RuleOverride changes an inherited rule without redefining it.
+type RuleOverride struct {
+	ID       string `json:"id" yaml:"id"`
+	Severity string `json:"severity,omitempty" yaml:"severity"`
+}
+
+// rulesRef is a reference from one rules file to another.
+type rulesRef struct {
+	kind string
+	path string
+}
+
+// loadRulesConfig loads the rules file at path and resolves the files it
+// extends or includes. stack holds the absolute paths of the files being
+// resolved, outermost first, and is used to detect cycles.
+func loadRulesConfig(path string, stack []string) (*RulesConfig, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if i := slices.Index(stack, abs); i >= 0 {
+		chain := append(append([]string(nil), stack[i:]...), abs)
+		return nil, fmt.Errorf("rules file %s: inheritance cycle: %s", path, strings.Join(chain, " -> "))
+	}
+	config, err := readRulesConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return resolveRulesConfig(config, path, append(stack, abs))
+}
+
+// resolveRulesConfig merges the files a config extends or includes, in that
+// order, then applies the config's overrides and its own rules. The result
+// is a flat config with no further references.
+func resolveRulesConfig(config *RulesConfig, path string, stack []string) (*RulesConfig, error) {
+	var refs []rulesRef
+	if config.Extends != "" {
+		refs = append(refs, rulesRef{kind: "extends", path: config.Extends})
+	}
+	for _, inc := range config.Include {
+		refs = append(refs, rulesRef{kind: "include", path: inc})
+	}
+	if len(refs) == 0 {
+		if len(config.Overrides) > 0 || len(config.Disable) > 0 {
+			return nil, fmt.Errorf("rules file %s: overrides and disable require extends or include", path)
+		}
+		return config, nil
+	}
+
+	resolved := &RulesConfig{}
+	for _, ref := range refs {
+		file := ref.path
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+		parent, err := loadRulesConfig(file, stack)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, ref.kind, err)
+		}
+		if ref.kind == "extends" {
+			resolved.Name, resolved.Description, resolved.Version = parent.Name, parent.Description, parent.Version
+		}
+		resolved.merge(parent)
+	}
+	if err := resolved.applyOverrides(config.Overrides, config.Disable); err != nil {
+		return nil, fmt.Errorf("rules file %s: %w", path, err)
+	}
+	if config.Name != "" {
+		resolved.Name = config.Name
+	}
+	if config.Description != "" {
+		resolved.Description = config.Description
+	}
+	if config.Version != "" {
+		resolved.Version = config.Version
+	}
+	resolved.merge(config)
+	return resolved, nil
+}
+
+// merge adds the rules and allowlists of other to c. A rule whose ID is
+// already present replaces the earlier definition in place, and a policy or
+// redaction strategy replaces any earlier one.
+func (c *RulesConfig) merge(other *RulesConfig) {
+	for _, rule := range other.Rules {
+		i := slices.IndexFunc(c.Rules, func(r Rule) bool { return rule.ID != "" && r.ID == rule.ID })
+		if i >= 0 {
+			c.Rules[i] = rule
+		} else {
+			c.Rules = append(c.Rules, rule)
+		}
+	}
+	if other.Allowlist != nil {
+		merged := Allowlist{}
+		if c.Allowlist != nil {
+			merged = *c.Allowlist
+		}
+		merged.Values = append(slices.Clip(merged.Values), other.Allowlist.Values...)
+		merged.Patterns = append(slices.Clip(merged.Patterns), other.Allowlist.Patterns...)
+		merged.Paths = append(slices.Clip(merged.Paths), other.Allowlist.Paths...)
+		merged.Hashes = append(slices.Clip(merged.Hashes), other.Allowlist.Hashes...)
+		c.Allowlist = &merged
+	}
+	c.Exclusions = append(c.Exclusions, other.Exclusions...)
+	c.DisableInlineSuppressions = c.DisableInlineSuppressions || other.DisableInlineSuppressions
+	if other.Policy != nil {
+		c.Policy = other.Policy
+	}
+	if other.Redact != nil {
+		c.Redact = other.Redact
+	}
+}
+
+// applyOverrides changes the severity of inherited rules and removes
+// disabled ones. Referencing a rule that was not inherited is an error.
+func (c *RulesConfig) applyOverrides(overrides []RuleOverride, disable []string) error {
+	for _, o := range overrides {
+		i := slices.IndexFunc(c.Rules, func(r Rule) bool { return r.ID == o.ID })
+		if i < 0 {
+			return fmt.Errorf("override of unknown inherited rule %q", o.ID)
+		}
+		if o.Severity != "" {
+			c.Rules[i].Severity = normalizeSeverity(o.Severity)
+		}
+	}
+	for _, id := range disable {
+		i := slices.IndexFunc(c.Rules, func(r Rule) bool { return r.ID == id })
+		if i < 0 {
+			return fmt.Errorf("disable of unknown inherited rule %q", id)
+		}
+		c.Rules = slices.Delete(c.Rules, i, i+1)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRulesFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLoadRulesConfigExtendsAndInclude(t *testing.T) {
+	dir := writeRulesFiles(t, map[string]string{
+		"base/classification.yaml": `
+name: Classification
+version: "1.0"
+rules:
+  - id: secret
+    pattern: SECRET
+    severity: high
+  - id: noforn
+    pattern: NOFORN
+    severity: high
+  - id: fouo
+    pattern: FOUO
+    severity: low
+allowlist:
+  values: ["SECRET SAUCE"]
+`,
+		"base/pii.yaml": `
+rules:
+  - id: ssn
+    pattern: '\d{3}-\d{2}-\d{4}'
+    severity: medium
+exclusions: ["000-00-0000"]
+`,
+		"team/rules.yaml": `
+extends: ../base/classification.yaml
+include: [../base/pii.yaml]
+name: Team rules
+overrides:
+  - id: ssn
+    severity: critical
+disable: [fouo]
+rules:
+  - id: noforn
+    pattern: '\bNOFORN\b'
+    severity: critical
+  - id: project
+    pattern: ORION
+    severity: high
+`,
+	})
+
+	config, err := LoadRulesConfig(filepath.Join(dir, "team/rules.yaml"))
+	if err != nil {
+		t.Fatalf("LoadRulesConfig failed: %v", err)
+	}
+	if config.Name != "Team rules" || config.Version != "1.0" {
+		t.Errorf("Unexpected metadata: %q %q", config.Name, config.Version)
+	}
+	var got []string
+	for _, r := range config.Rules {
+		got = append(got, r.ID+":"+r.Severity)
+	}
+	want := "secret:high noforn:critical ssn:critical project:high"
+	if strings.Join(got, " ") != want {
+		t.Errorf("Expected rules %q, got %q", want, strings.Join(got, " "))
+	}
+	if config.Rules[1].Pattern != `\bNOFORN\b` {
+		t.Errorf("Expected local rule to replace inherited one, got %q", config.Rules[1].Pattern)
+	}
+	if config.Allowlist == nil || len(config.Allowlist.Values) != 1 || len(config.Exclusions) != 1 {
+		t.Errorf("Expected inherited allowlist and exclusions, got %+v %v", config.Allowlist, config.Exclusions)
+	}
+	if _, err := CompileRulesConfig(config); err != nil {
+		t.Errorf("Resolved config failed to compile: %v", err)
+	}
+}
+
+func TestLoadRulesConfigInheritanceErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"a.yaml": "extends: b.yaml\n",
+				"b.yaml": "include: [c.yaml]\n",
+				"c.yaml": "extends: a.yaml\n",
+			},
+			want: []string{"inheritance cycle", "a.yaml -> ", "b.yaml -> ", "c.yaml -> "},
+		},
+		{
+			name:  "missing parent",
+			files: map[string]string{"a.yaml": "extends: missing.yaml\n"},
+			want:  []string{"a.yaml: extends:", "missing.yaml"},
+		},
+		{
+			name: "invalid nested yaml",
+			files: map[string]string{
+				"a.yaml":   "include: [bad.yaml]\n",
+				"bad.yaml": "rules: [",
+			},
+			want: []string{"a.yaml: include: rules file", "bad.yaml"},
+		},
+		{
+			name: "unknown override",
+			files: map[string]string{
+				"a.yaml": "extends: b.yaml\noverrides:\n  - id: nope\n    severity: low\n",
+				"b.yaml": "rules:\n  - id: r\n    pattern: x\n",
+			},
+			want: []string{"a.yaml", `unknown inherited rule "nope"`},
+		},
+		{
+			name:  "disable without parent",
+			files: map[string]string{"a.yaml": "disable: [r]\nrules:\n  - id: r\n    pattern: x\n"},
+			want:  []string{"a.yaml", "require extends or include"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeRulesFiles(t, tt.files)
+			_, err := LoadRulesConfig(filepath.Join(dir, "a.yaml"))
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("Expected error to contain %q, got %v", w, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadRulesConfigSharedInclude(t *testing.T) {
+	dir := writeRulesFiles(t, map[string]string{
+		"a.yaml":      "include: [b.yaml, c.yaml]\n",
+		"b.yaml":      "include: [common.yaml]\n",
+		"c.yaml":      "include: [common.yaml]\n",
+		"common.yaml": "rules:\n  - id: r\n    pattern: x\n",
+	})
+	config, err := LoadRulesConfig(filepath.Join(dir, "a.yaml"))
+	if err != nil {
+		t.Fatalf("Expected shared include not to be a cycle: %v", err)
+	}
+	if len(config.Rules) != 1 {
+		t.Errorf("Expected 1 rule, got %d", len(config.Rules))
+	}
+}
+
+func TestCompileRulesConfigRejectsUnresolvedExtends(t *testing.T) {
+	if _, err := CompileRulesConfig(&RulesConfig{Extends: "base.yaml"}); err == nil {
+		t.Error("Expected error for unresolved extends")
+	}
+}
@@ -0,0 +1,75 @@
+package engine
+
+import "sync/atomic"
+
+// DefaultMaxFindings is the default cap on the findings reported for one
+// document.
+const DefaultMaxFindings = 10000
+
+// Limits bound the findings reported for one document, so that pathological
+// inputs cannot produce unbounded results. Zero disables a limit. Results
+// that hit a limit are marked Truncated.
+type Limits struct {
+	// MaxFindings caps the findings, and separately the suppressed
+	// findings, of a result. The earliest findings in the document are kept.
+	MaxFindings int
+	// MaxMatchesPerRule caps the matches of each rule that does not set its
+	// own max_matches.
+	MaxMatchesPerRule int
+}
+
+var limits atomic.Pointer[Limits]
+
+func init() {
+	limits.Store(&Limits{MaxFindings: DefaultMaxFindings})
+}
+
+// SetLimits sets the limits applied to every scan.
+func SetLimits(l Limits) {
+	limits.Store(&l)
+}
+
+// GetLimits returns the limits applied to every scan.
+func GetLimits() Limits {
+	return *limits.Load()
+}
+
+// matchCap returns the maximum number of matches kept for a rule, or zero.
+func (l Limits) matchCap(rule Rule) int {
+	if rule.MaxMatches > 0 {
+		return rule.MaxMatches
+	}
+	return l.MaxMatchesPerRule
+}
+
+// taskCap returns how many unsuppressed findings a task evaluating the rule
+// needs to collect: one more than the tighter of the rule and result caps,
+// so the caller can tell the cap was exceeded. Zero means no cap.
+func (l Limits) taskCap(rule Rule) int {
+	n := l.matchCap(rule)
+	if l.MaxFindings > 0 && (n == 0 || l.MaxFindings < n) {
+		n = l.MaxFindings
+	}
+	if n == 0 {
+		return 0
+	}
+	return n + 1
+}
+
+// suppressedCap returns how many suppressed findings a task needs to
+// collect: one more than the result cap, or zero for no cap.
+func (l Limits) suppressedCap() int {
+	if l.MaxFindings > 0 {
+		return l.MaxFindings + 1
+	}
+	return 0
+}
+
+// truncate drops the findings beyond the result cap and reports whether any
+// were dropped.
+func (l Limits) truncate(findings []Finding) ([]Finding, bool) {
+	if l.MaxFindings > 0 && len(findings) > l.MaxFindings {
+		return findings[:l.MaxFindings], true
+	}
+	return findings, false
+}
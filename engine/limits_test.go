@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxFindingsKeepsEarliest(t *testing.T) {
+	defer SetLimits(GetLimits())
+	SetLimits(Limits{MaxFindings: 5})
+
+	set, err := CompileRules([]Rule{
+		{ID: "b", Pattern: "beta"},
+		{ID: "a", Pattern: "alpha"},
+	})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	text := strings.Repeat("alpha beta\n", 20)
+	result := set.Scan(text, "doc.txt")
+	if !result.Truncated || len(result.Findings) != 5 {
+		t.Fatalf("Expected 5 findings and truncation, got %d truncated=%v", len(result.Findings), result.Truncated)
+	}
+	if last := result.Findings[4]; last.Line != 3 || last.RuleID != "b" {
+		t.Errorf("Expected the earliest findings to be kept, last is %s on line %d", last.RuleID, last.Line)
+	}
+
+	streamed, err := set.ScanReader(context.Background(), strings.NewReader(text), "doc.txt")
+	if err != nil {
+		t.Fatalf("ScanReader failed: %v", err)
+	}
+	if !streamed.Truncated || len(streamed.Findings) != 5 || !reflect.DeepEqual(streamed.Findings, result.Findings) {
+		t.Errorf("Expected ScanReader to keep the same findings, got %+v", streamed.Findings)
+	}
+
+	if result := set.Scan("alpha beta", "doc.txt"); result.Truncated {
+		t.Error("Expected a result under the cap not to be truncated")
+	}
+}
+
+func TestMatchCaps(t *testing.T) {
+	defer SetLimits(GetLimits())
+	SetLimits(Limits{MaxMatchesPerRule: 3})
+
+	set, err := CompileRules([]Rule{
+		{ID: "capped", Pattern: "alpha"},
+		{ID: "own-cap", Pattern: "beta", MaxMatches: 1},
+		{ID: "under", Pattern: "gamma"},
+	})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	result := set.Scan(strings.Repeat("alpha beta\n", 10)+"gamma gamma", "doc.txt")
+	counts := make(map[string]int)
+	for _, f := range result.Findings {
+		counts[f.RuleID]++
+	}
+	if counts["capped"] != 3 || counts["own-cap"] != 1 || counts["under"] != 2 || !result.Truncated {
+		t.Errorf("Expected 3, 1 and 2 matches with truncation, got %v truncated=%v", counts, result.Truncated)
+	}
+	if result.Findings[0].Line != 1 || result.Findings[2].Line != 2 {
+		t.Errorf("Expected capped matches to be the earliest, got %+v", result.Findings)
+	}
+
+	if _, err := CompileRules([]Rule{{ID: "bad", Pattern: "x", MaxMatches: -1}}); err == nil {
+		t.Error("Expected an error for a negative max_matches")
+	}
+}
+
+func TestCapsCountAfterSuppression(t *testing.T) {
+	defer SetLimits(GetLimits())
+	SetLimits(Limits{MaxFindings: 4, MaxMatchesPerRule: 2})
+
+	set, err := CompileRules([]Rule{
+		{ID: "token", Pattern: `tok_\w+`, Allowlist: &Allowlist{Values: []string{"tok_example"}}},
+	})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	text := strings.Repeat("tok_example\n", 10) +
+		strings.Repeat("tok_fixture # dws:ignore\n", 10) +
+		"tok_real1\ntok_real2\n"
+	result := set.Scan(text, "doc.txt")
+	if len(result.Findings) != 2 || result.Findings[0].Match != "tok_real1" || result.Findings[1].Match != "tok_real2" {
+		t.Fatalf("Expected the findings after the suppressed matches, got %+v", result.Findings)
+	}
+	if len(result.Suppressed) != 4 || !result.Truncated {
+		t.Errorf("Expected 4 suppressed findings with truncation, got %d truncated=%v", len(result.Suppressed), result.Truncated)
+	}
+
+	streamed, err := set.ScanReader(context.Background(), strings.NewReader(text), "doc.txt")
+	if err != nil {
+		t.Fatalf("ScanReader failed: %v", err)
+	}
+	if !reflect.DeepEqual(streamed.Findings, result.Findings) {
+		t.Errorf("Expected ScanReader to keep the same findings, got %+v", streamed.Findings)
+	}
+
+	if result := set.Scan(strings.Repeat("tok_example\n", 3)+"tok_real1", "doc.txt"); result.Truncated || len(result.Findings) != 1 {
+		t.Errorf("Expected suppressed matches not to use up the rule cap, got %+v truncated=%v", result.Findings, result.Truncated)
+	}
+}
+
+func TestScanContextCancelled(t *testing.T) {
+	set, err := CompileRules([]Rule{{ID: "secret", Pattern: "SECRET"}})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	text := strings.Repeat("SECRET\n", 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := set.ScanContext(ctx, text, "doc.txt"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from ScanContext, got %v", err)
+	}
+	if _, err := set.ScanReader(ctx, strings.NewReader(text), "doc.txt"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from ScanReader, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if _, err := EvaluateContext(ctx, text, "doc.txt", []Rule{{ID: "secret", Pattern: "SECRET"}}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded from EvaluateContext, got %v", err)
+	}
+}
@@ -0,0 +1,349 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic levels. Errors make a rules file invalid; warnings flag rules
+// that load but probably do not behave as intended.
+const (
+	DiagnosticError   = "error"
+	DiagnosticWarning = "warning"
+)
+
+// Diagnostic is a problem found while linting a rules file. Line and Column
+// are 1-based positions in the YAML source, or zero when unknown.
+type Diagnostic struct {
+	Level   string `json:"level"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	RuleID  string `json:"rule_id,omitempty"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%d:%d: %s: ", d.Line, d.Column, d.Level)
+	if d.RuleID != "" {
+		s += "rule " + d.RuleID + ": "
+	}
+	return s + d.Message
+}
+
+// HasErrors reports whether any diagnostic is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Level == DiagnosticError {
+			return true
+		}
+	}
+	return false
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+):`)
+
+// LintRulesFile lints the rules file at path.
+func LintRulesFile(path string) ([]Diagnostic, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LintRules(data, path), nil
+}
+
+// LintRules checks rules YAML for problems that loading would accept or only
+// report at scan time: unknown fields, missing or duplicate IDs, patterns
+// that fail to compile or match the empty string, unknown severities,
+// broken composite references, invalid policy thresholds and unknown
+// redaction strategies. path locates extended or included files; when it is
+// empty those references are not resolved.
+func LintRules(data []byte, path string) []Diagnostic {
+	l := &linter{}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		line := 0
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+		}
+		l.report(DiagnosticError, line, 0, "", "invalid YAML: %v", err)
+		return l.diags
+	}
+	if len(root.Content) == 0 {
+		l.report(DiagnosticError, 0, 0, "", "rules file is empty")
+		return l.diags
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		l.report(DiagnosticError, doc.Line, doc.Column, "", "rules file must be a mapping with a rules list")
+		return l.diags
+	}
+
+	var rulesNode, policyKey, policyNode *yaml.Node
+	configType := reflect.TypeOf(RulesConfig{})
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		if key.Value == "rules" {
+			rulesNode = value
+			continue
+		}
+		if key.Value == "policy" {
+			policyKey, policyNode = key, value
+		}
+		if key.Value == "redact" {
+			l.checkRedact(key, value)
+		}
+		l.checkField(key, value, configType, "")
+	}
+	if rulesNode == nil || rulesNode.Kind != yaml.SequenceNode {
+		l.report(DiagnosticError, doc.Line, doc.Column, "", "rules file has no rules list")
+		return l.diags
+	}
+
+	inherited := l.resolveInherited(doc, path)
+	available := l.lintRules(rulesNode, inherited, path)
+	if policyNode != nil {
+		l.checkPolicy(policyKey, policyNode, available)
+	}
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		return l.diags[i].Line < l.diags[j].Line
+	})
+	return l.diags
+}
+
+type linter struct {
+	diags []Diagnostic
+}
+
+func (l *linter) report(level string, line, column int, ruleID, format string, args ...any) {
+	l.diags = append(l.diags, Diagnostic{
+		Level:   level,
+		Line:    line,
+		Column:  column,
+		RuleID:  ruleID,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// resolveInherited loads the files a rules file extends or includes and
+// returns the IDs of the rules they provide.
+func (l *linter) resolveInherited(doc *yaml.Node, path string) map[string]bool {
+	var refKey *yaml.Node
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if k := doc.Content[i]; k.Value == "extends" || k.Value == "include" {
+			refKey = k
+			break
+		}
+	}
+	if refKey == nil {
+		return nil
+	}
+	if path == "" {
+		l.report(DiagnosticWarning, refKey.Line, refKey.Column, "", "extends and include are not resolved when linting uploaded rules")
+		return nil
+	}
+	config, err := LoadRulesConfig(path)
+	if err != nil {
+		l.report(DiagnosticError, refKey.Line, refKey.Column, "", "%v", err)
+		return nil
+	}
+	ids := make(map[string]bool)
+	for _, r := range config.Rules {
+		if r.Type != RuleTypeComposite {
+			ids[r.ID] = true
+		}
+	}
+	return ids
+}
+
+// lintRules checks each rule in the rules sequence and returns the IDs of
+// the rules available to the ruleset. Dictionary files are resolved
+// relative to path, as when the rules file is loaded.
+func (l *linter) lintRules(rulesNode *yaml.Node, inherited map[string]bool, path string) map[string]bool {
+	ruleType := reflect.TypeOf(Rule{})
+	firstLine := make(map[string]int)
+	available := make(map[string]bool)
+	for id := range inherited {
+		available[id] = true
+	}
+	type composite struct {
+		rule Rule
+		node *yaml.Node
+	}
+	var composites []composite
+
+	for _, node := range rulesNode.Content {
+		if node.Kind != yaml.MappingNode {
+			l.report(DiagnosticError, node.Line, node.Column, "", "rule must be a mapping")
+			continue
+		}
+		var rule Rule
+		if err := node.Decode(&rule); err != nil {
+			l.report(DiagnosticError, node.Line, node.Column, "", "invalid rule: %v", err)
+			continue
+		}
+		rule.normalize()
+		if path != "" {
+			resolveDictionaryFile(&rule, filepath.Dir(path))
+		}
+		id := rule.ID
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			l.checkField(node.Content[i], node.Content[i+1], ruleType, id)
+		}
+
+		if id == "" {
+			l.report(DiagnosticError, node.Line, node.Column, "", "rule has no id or name")
+		} else if line, ok := firstLine[id]; ok {
+			l.report(DiagnosticError, node.Line, node.Column, id, "duplicate rule id, first defined on line %d", line)
+		} else {
+			firstLine[id] = node.Line
+		}
+
+		l.checkSeverity(rule, node)
+		if rule.Type == RuleTypeRegex {
+			if rule.Pattern == "" {
+				l.report(DiagnosticError, node.Line, node.Column, id, "pattern is empty")
+				continue
+			}
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				key := fieldNode(node, "pattern")
+				l.report(DiagnosticError, key.Line, key.Column, id, "invalid pattern: %v", err)
+				continue
+			}
+			if re.MatchString("") {
+				key := fieldNode(node, "pattern")
+				l.report(DiagnosticWarning, key.Line, key.Column, id, "pattern matches the empty string")
+			}
+		}
+		if _, err := compileRule(rule); err != nil {
+			l.report(DiagnosticError, node.Line, node.Column, id, "%v", err)
+			continue
+		}
+		if rule.Type == RuleTypeComposite {
+			composites = append(composites, composite{rule, node})
+			continue
+		}
+		available[id] = true
+	}
+
+	for _, c := range composites {
+		if err := checkCompositeRefs(c.rule, available); err != nil {
+			key := fieldNode(c.node, "composite")
+			l.report(DiagnosticError, key.Line, key.Column, c.rule.ID, "%v", err)
+			continue
+		}
+		available[c.rule.ID] = true
+	}
+	return available
+}
+
+// checkPolicy validates the policy thresholds and the rules they reference.
+func (l *linter) checkPolicy(key, value *yaml.Node, available map[string]bool) {
+	var policy Policy
+	if err := value.Decode(&policy); err != nil {
+		l.report(DiagnosticError, key.Line, key.Column, "", "invalid policy: %v", err)
+		return
+	}
+	if _, err := compilePolicy(&policy, available); err != nil {
+		l.report(DiagnosticError, key.Line, key.Column, "", "%v", err)
+	}
+}
+
+// checkRedact validates the ruleset-wide redaction options.
+func (l *linter) checkRedact(key, value *yaml.Node) {
+	var opts RedactOptions
+	if err := value.Decode(&opts); err != nil {
+		l.report(DiagnosticError, key.Line, key.Column, "", "invalid redact: %v", err)
+		return
+	}
+	if err := validateRedact(&opts); err != nil {
+		l.report(DiagnosticError, key.Line, key.Column, "", "%v", err)
+	}
+}
+
+// checkSeverity warns about missing severities and ones outside the
+// known vocabulary.
+func (l *linter) checkSeverity(rule Rule, node *yaml.Node) {
+	if rule.Severity == "" {
+		l.report(DiagnosticWarning, node.Line, node.Column, rule.ID, "rule has no severity")
+		return
+	}
+	if _, ok := ParseSeverity(rule.Severity); !ok {
+		key := fieldNode(node, "severity")
+		l.report(DiagnosticWarning, key.Line, key.Column, rule.ID, "unknown severity %q", rule.Severity)
+	}
+}
+
+// checkField reports key if it is not a field of t, then checks nested
+// mappings and sequences against the field's type.
+func (l *linter) checkField(key, value *yaml.Node, t reflect.Type, ruleID string) {
+	fields := yamlFields(t)
+	ft, ok := fields[key.Value]
+	if !ok {
+		msg := fmt.Sprintf("unknown field %q", key.Value)
+		for name := range fields {
+			if strings.EqualFold(name, key.Value) {
+				msg += fmt.Sprintf(" (did you mean %q?)", name)
+				break
+			}
+		}
+		l.report(DiagnosticWarning, key.Line, key.Column, ruleID, "%s", msg)
+		return
+	}
+	l.checkValue(value, ft, ruleID)
+}
+
+func (l *linter) checkValue(value *yaml.Node, t reflect.Type, ruleID string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Struct && value.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			l.checkField(value.Content[i], value.Content[i+1], t, ruleID)
+		}
+	case t.Kind() == reflect.Slice && value.Kind == yaml.SequenceNode:
+		for _, item := range value.Content {
+			l.checkValue(item, t.Elem(), ruleID)
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of a struct to their field types.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// fieldNode returns the key node of field in a mapping node, or the mapping
+// itself if the field is absent.
+func fieldNode(node *yaml.Node, field string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == field {
+			return node.Content[i]
+		}
+	}
+	return node
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintRules(t *testing.T) {
+	yaml := `name: test
+verison: "1.0"
+rules:
+  - id: ok
+    pattern: secret
+    severity: high
+  - id: ok
+    pattern: other
+    severity: HIGH
+  - pattern: nameless
+    severity: low
+  - id: bad-regex
+    pattern: "[unclosed"
+    severity: low
+  - id: empty-match
+    pattern: "x*"
+    severity: spicy
+  - id: typo
+    Pattern: secret
+    severity: low
+    tags: [a]
+  - id: combo
+    type: composite
+    severity: high
+    composite:
+      all: [ok, missing]
+      witihn_lines: 3
+`
+	diags := LintRules([]byte(yaml), "")
+	want := []struct {
+		level, rule, contains string
+		line                  int
+	}{
+		{DiagnosticWarning, "", `unknown field "verison"`, 2},
+		{DiagnosticError, "ok", "duplicate rule id, first defined on line 4", 7},
+		{DiagnosticError, "", "rule has no id or name", 10},
+		{DiagnosticError, "bad-regex", "invalid pattern", 13},
+		{DiagnosticWarning, "empty-match", "unknown severity", 17},
+		{DiagnosticWarning, "empty-match", "matches the empty string", 16},
+		{DiagnosticWarning, "typo", `unknown field "Pattern" (did you mean "pattern"?)`, 19},
+		{DiagnosticError, "typo", "pattern is empty", 18},
+		{DiagnosticWarning, "combo", `unknown field "witihn_lines"`, 27},
+		{DiagnosticError, "combo", `unknown or invalid rule "missing"`, 25},
+	}
+	if len(diags) != len(want) {
+		t.Errorf("Expected %d diagnostics, got %d:\n%v", len(want), len(diags), diags)
+	}
+	for _, w := range want {
+		found := false
+		for _, d := range diags {
+			if d.Level == w.level && d.RuleID == w.rule && d.Line == w.line && strings.Contains(d.Message, w.contains) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("Missing %s on line %d for rule %q containing %q in:\n%v", w.level, w.line, w.rule, w.contains, diags)
+		}
+	}
+	if !HasErrors(diags) {
+		t.Error("Expected HasErrors to be true")
+	}
+}
+
+func TestLintRulesInvalidYAML(t *testing.T) {
+	diags := LintRules([]byte("rules:\n  - id: a\n    pattern: [\n"), "")
+	if len(diags) != 1 || diags[0].Level != DiagnosticError || diags[0].Line == 0 {
+		t.Errorf("Expected one YAML error with a line number, got %v", diags)
+	}
+	diags = LintRules([]byte("name: nothing\n"), "")
+	if len(diags) != 1 || !strings.Contains(diags[0].Message, "no rules list") {
+		t.Errorf("Expected missing rules error, got %v", diags)
+	}
+}
+
+func TestLintRulesResolvesInheritance(t *testing.T) {
+	dir := writeRulesFiles(t, map[string]string{
+		"base.yaml": "rules:\n  - id: banner\n    pattern: SECRET\n    severity: high\n",
+		"team.yaml": "extends: base.yaml\nrules:\n  - id: combo\n    type: composite\n    severity: high\n    composite:\n      all: [banner]\n",
+		"bad.yaml":  "extends: missing.yaml\nrules: []\n",
+	})
+	diags, err := LintRulesFile(filepath.Join(dir, "team.yaml"))
+	if err != nil || len(diags) != 0 {
+		t.Errorf("Expected inherited rule references to lint cleanly, got %v %v", diags, err)
+	}
+	diags, err = LintRulesFile(filepath.Join(dir, "bad.yaml"))
+	if err != nil || len(diags) != 1 || diags[0].Line != 1 || !strings.Contains(diags[0].Message, "missing.yaml") {
+		t.Errorf("Expected extends error on line 1, got %v %v", diags, err)
+	}
+}
+
+func TestLintRulesResolvesDictionaryFiles(t *testing.T) {
+	dir := writeRulesFiles(t, map[string]string{
+		"terms.txt":  "raccoon\n",
+		"rules.yaml": "rules:\n  - id: codenames\n    type: dictionary\n    severity: high\n    dictionary:\n      file: terms.txt\n",
+	})
+	t.Chdir(t.TempDir())
+	diags, err := LintRulesFile(filepath.Join(dir, "rules.yaml"))
+	if err != nil || len(diags) != 0 {
+		t.Errorf("Expected dictionary file relative to the rules file to lint cleanly, got %v %v", diags, err)
+	}
+}
+
+func TestLintConfigRules(t *testing.T) {
+	for _, path := range []string{"../config/rules.yaml", "../config/default.yaml"} {
+		diags, err := LintRulesFile(path)
+		if err != nil {
+			t.Fatalf("LintRulesFile(%s) failed: %v", path, err)
+		}
+		if len(diags) != 0 {
+			t.Errorf("Expected %s to lint cleanly, got:\n%v", path, diags)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+)
+
+// PageBreak separates the pages of paged documents, such as PDFs, in
+// extracted text. It starts the first line of every page after the first,
+// so line numbers are unaffected. Findings in text containing page breaks
+// report the page they are on.
+const PageBreak = "\f"
+
+// pageBreaks returns the offsets of the page breaks in text, which starts
+// at offset base.
+func pageBreaks(text string, base int) []int {
+	var breaks []int
+	for i := strings.Index(text, PageBreak); i >= 0; {
+		breaks = append(breaks, base+i)
+		j := strings.Index(text[i+1:], PageBreak)
+		if j < 0 {
+			break
+		}
+		i += j + 1
+	}
+	return breaks
+}
+
+// setPages numbers the pages of findings, and of the findings that
+// triggered them, from the page breaks of their document. Findings are left
+// without a page when the document has no page breaks.
+func setPages(findings []Finding, breaks []int) {
+	if len(breaks) == 0 {
+		return
+	}
+	for i := range findings {
+		findings[i].Page = 1 + sort.SearchInts(breaks, findings[i].StartOffset)
+		setPages(findings[i].Contributing, breaks)
+	}
+}
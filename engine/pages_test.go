@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFindingPages(t *testing.T) {
+	set, err := CompileRules([]Rule{
+		{ID: "secret", Pattern: "secret", Severity: "high"},
+		{ID: "key", Pattern: "key", Severity: "low"},
+		{ID: "pair", Type: RuleTypeComposite, Severity: "critical", Composite: &CompositeOptions{All: []string{"secret", "key"}, WithinChars: 10}},
+	})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	text := "secret on page one\n\fpage two\n\fsecret key on page three"
+	want := []struct {
+		rule string
+		page int
+	}{{"secret", 1}, {"secret", 3}, {"key", 3}, {"pair", 3}}
+
+	result := set.Scan(text, "doc.pdf")
+	streamed, err := set.ScanReader(context.Background(), iotest.OneByteReader(strings.NewReader(text)), "doc.pdf")
+	if err != nil {
+		t.Fatalf("ScanReader failed: %v", err)
+	}
+	for _, findings := range [][]Finding{result.Findings, streamed.Findings} {
+		if len(findings) != len(want) {
+			t.Fatalf("Expected %d findings, got %+v", len(want), findings)
+		}
+		for i, w := range want {
+			if findings[i].RuleID != w.rule || findings[i].Page != w.page {
+				t.Errorf("Finding %d: expected %s on page %d, got %s on page %d", i, w.rule, w.page, findings[i].RuleID, findings[i].Page)
+			}
+		}
+		if c := findings[3].Contributing; len(c) != 2 || c[0].Page != 3 {
+			t.Errorf("Expected contributing findings on page 3, got %+v", c)
+		}
+	}
+}
+
+func TestFindingPagesUnpaged(t *testing.T) {
+	findings := Evaluate("a secret", "doc.txt", []Rule{{ID: "secret", Pattern: "secret"}})
+	if len(findings) != 1 || findings[0].Page != 0 {
+		t.Fatalf("Expected no page outside paged documents, got %+v", findings)
+	}
+}
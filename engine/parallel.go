@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelChunkLines is the number of lines each task matches a line-scoped
+// rule against, so that long documents are shared between workers even when
+// the rule set is small.
+const parallelChunkLines = 1024
+
+var concurrency atomic.Int64
+
+// SetConcurrency sets how many goroutines evaluate rules against a single
+// document. Zero or a negative value uses one per CPU available to the
+// process, which is the default.
+func SetConcurrency(n int) {
+	concurrency.Store(int64(n))
+}
+
+// GetConcurrency returns the number of goroutines used to evaluate a document.
+func GetConcurrency() int {
+	if n := int(concurrency.Load()); n > 0 {
+		return n
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// ruleTask evaluates one rule against part of a document. Tasks only read
+// the document and compiled rule, so they can run concurrently.
+type ruleTask struct {
+	cr  compiledRule
+	doc *document
+	run func() []Finding
+}
+
+// runTasks runs tasks on up to GetConcurrency goroutines and returns their
+// findings in task order, so results do not depend on scheduling.
+func runTasks(tasks []ruleTask) [][]Finding {
+	results := make([][]Finding, len(tasks))
+	workers := min(GetConcurrency(), len(tasks))
+	if workers <= 1 {
+		for i, t := range tasks {
+			results[i] = t.run()
+		}
+		return results
+	}
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= len(tasks) {
+					return
+				}
+				results[i] = tasks[i].run()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
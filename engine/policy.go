@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verdicts, from least to most restrictive.
+const (
+	VerdictAllow      = "allow"
+	VerdictWarn       = "warn"
+	VerdictQuarantine = "quarantine"
+	VerdictBlock      = "block"
+)
+
+var verdictRank = map[string]int{
+	VerdictAllow:      0,
+	VerdictWarn:       1,
+	VerdictQuarantine: 2,
+	VerdictBlock:      3,
+}
+
+// Policy turns the findings for a document into a verdict. The most
+// restrictive verdict among the triggered thresholds wins; a document that
+// triggers none is allowed.
+type Policy struct {
+	Thresholds []Threshold `json:"thresholds" yaml:"thresholds"`
+}
+
+// Threshold triggers Verdict when a document has at least MinCount findings
+// (one if unset) at or above Severity, counting only the findings of Rules
+// when it is set. A non-zero MinRiskScore additionally requires the
+// document's risk score to reach it.
+type Threshold struct {
+	Verdict      string   `json:"verdict" yaml:"verdict"`
+	Severity     string   `json:"severity,omitempty" yaml:"severity"`
+	Rules        []string `json:"rules,omitempty" yaml:"rules"`
+	MinCount     int      `json:"min_count,omitempty" yaml:"min_count"`
+	MinRiskScore int      `json:"min_risk_score,omitempty" yaml:"min_risk_score"`
+}
+
+// Decision is the verdict for a document and the thresholds that led to it.
+type Decision struct {
+	Verdict string   `json:"verdict"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// compiledThreshold is a Threshold with its severity and rules resolved.
+type compiledThreshold struct {
+	Threshold
+	min   Severity
+	rules map[string]bool
+}
+
+// compiledPolicy is a validated Policy. A nil compiledPolicy is the default
+// policy, which warns about any finding.
+type compiledPolicy struct {
+	thresholds []compiledThreshold
+}
+
+// compilePolicy validates a policy. Rule references are checked against
+// known when it is non-nil.
+func compilePolicy(p *Policy, known map[string]bool) (*compiledPolicy, error) {
+	if p == nil {
+		return nil, nil
+	}
+	c := &compiledPolicy{}
+	for i, t := range p.Thresholds {
+		ct := compiledThreshold{Threshold: t}
+		ct.Verdict = strings.ToLower(strings.TrimSpace(t.Verdict))
+		if rank, ok := verdictRank[ct.Verdict]; !ok || rank == 0 {
+			return nil, fmt.Errorf("policy threshold %d: verdict must be warn, quarantine or block, got %q", i+1, t.Verdict)
+		}
+		if t.Severity != "" {
+			sev, ok := ParseSeverity(t.Severity)
+			if !ok {
+				return nil, fmt.Errorf("policy threshold %d: unknown severity %q", i+1, t.Severity)
+			}
+			ct.min = sev
+		}
+		if t.MinCount < 0 || t.MinRiskScore < 0 {
+			return nil, fmt.Errorf("policy threshold %d: min_count and min_risk_score must not be negative", i+1)
+		}
+		if ct.MinCount == 0 {
+			ct.MinCount = 1
+		}
+		if len(t.Rules) > 0 {
+			ct.rules = make(map[string]bool, len(t.Rules))
+			for _, id := range t.Rules {
+				if known != nil && !known[id] {
+					return nil, fmt.Errorf("policy threshold %d: unknown rule %q", i+1, id)
+				}
+				ct.rules[id] = true
+			}
+		}
+		c.thresholds = append(c.thresholds, ct)
+	}
+	return c, nil
+}
+
+// decide returns the verdict for a document's findings.
+func (c *compiledPolicy) decide(findings []Finding) Decision {
+	if c == nil {
+		if len(findings) == 0 {
+			return Decision{Verdict: VerdictAllow}
+		}
+		return Decision{Verdict: VerdictWarn, Reasons: []string{fmt.Sprintf("%d finding(s)", len(findings))}}
+	}
+	decision := Decision{Verdict: VerdictAllow}
+	risk := RiskScore(findings)
+	for _, t := range c.thresholds {
+		reason, ok := t.triggered(findings, risk)
+		if !ok {
+			continue
+		}
+		switch rank := verdictRank[t.Verdict]; {
+		case rank > verdictRank[decision.Verdict]:
+			decision = Decision{Verdict: t.Verdict, Reasons: []string{reason}}
+		case rank == verdictRank[decision.Verdict]:
+			decision.Reasons = append(decision.Reasons, reason)
+		}
+	}
+	return decision
+}
+
+// triggered reports whether the threshold is met and describes why.
+func (t compiledThreshold) triggered(findings []Finding, risk int) (string, bool) {
+	count := 0
+	for _, f := range findings {
+		if f.Level() < t.min {
+			continue
+		}
+		if t.rules != nil && !t.rules[f.RuleID] {
+			continue
+		}
+		count++
+	}
+	if count < t.MinCount || risk < t.MinRiskScore {
+		return "", false
+	}
+	reason := fmt.Sprintf("%d finding(s)", count)
+	if t.min != SeverityUnknown {
+		reason += " at or above " + t.min.String()
+	}
+	if len(t.Rules) > 0 {
+		reason += " from " + strings.Join(t.Rules, ", ")
+	}
+	reason += fmt.Sprintf(" (min %d)", t.MinCount)
+	if t.MinRiskScore > 0 {
+		reason += fmt.Sprintf(", risk score %d (min %d)", risk, t.MinRiskScore)
+	}
+	return reason, true
+}
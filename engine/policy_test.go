@@ -0,0 +1,146 @@
+package engine
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPolicyVerdicts(t *testing.T) {
+	config, err := ParseRulesConfig([]byte(`
+rules:
+  - id: secret
+    pattern: SECRET
+    severity: high
+  - id: topsecret
+    pattern: TOP SECRET
+    severity: critical
+  - id: note
+    pattern: note
+    severity: info
+policy:
+  thresholds:
+    - verdict: block
+      severity: critical
+    - verdict: quarantine
+      severity: high
+      min_count: 4
+    - verdict: warn
+      rules: [note]
+    - verdict: warn
+      min_risk_score: 20
+`))
+	if err != nil {
+		t.Fatalf("ParseRulesConfig failed: %v", err)
+	}
+	set, err := CompileRulesConfig(config)
+	if err != nil {
+		t.Fatalf("CompileRulesConfig failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		verdict string
+		reasons []string
+	}{
+		{"clean", "nothing here", VerdictAllow, nil},
+		{"note", "a note", VerdictWarn, []string{"1 finding(s) from note (min 1)"}},
+		{"three high", "SECRET\nSECRET\nSECRET", VerdictWarn, []string{"3 finding(s) (min 1), risk score 21 (min 20)"}},
+		{"four high", "SECRET\nSECRET\nSECRET\nSECRET", VerdictQuarantine, []string{"4 finding(s) at or above high (min 4)"}},
+		{"critical", "TOP SECRET", VerdictBlock, []string{"1 finding(s) at or above critical (min 1)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := set.Scan(tt.text, "doc.txt").Decision
+			if d.Verdict != tt.verdict || strings.Join(d.Reasons, "|") != strings.Join(tt.reasons, "|") {
+				t.Errorf("Expected %s %q, got %s %q", tt.verdict, tt.reasons, d.Verdict, d.Reasons)
+			}
+		})
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	set, err := CompileRules([]Rule{{ID: "secret", Pattern: "SECRET", Severity: "low"}})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	if d := set.Scan("clean", "doc.txt").Decision; d.Verdict != VerdictAllow || len(d.Reasons) != 0 {
+		t.Errorf("Expected allow for a clean document, got %+v", d)
+	}
+	if d := set.Scan("SECRET SECRET", "doc.txt").Decision; d.Verdict != VerdictWarn || d.Reasons[0] != "2 finding(s)" {
+		t.Errorf("Expected warn for findings, got %+v", d)
+	}
+	var empty *CompiledRuleSet
+	if d := empty.Scan("SECRET", "doc.txt").Decision; d.Verdict != VerdictAllow {
+		t.Errorf("Expected allow without a rule set, got %+v", d)
+	}
+}
+
+func TestCompilePolicyErrors(t *testing.T) {
+	rules := []Rule{{ID: "secret", Pattern: "SECRET", Severity: "high"}}
+	tests := []struct {
+		threshold Threshold
+		want      string
+	}{
+		{Threshold{Verdict: "reject"}, "verdict must be"},
+		{Threshold{Verdict: "allow"}, "verdict must be"},
+		{Threshold{Verdict: "block", Severity: "spicy"}, `unknown severity "spicy"`},
+		{Threshold{Verdict: "block", MinCount: -1}, "must not be negative"},
+		{Threshold{Verdict: "block", Rules: []string{"missing"}}, `unknown rule "missing"`},
+	}
+	for _, tt := range tests {
+		config := &RulesConfig{Rules: rules, Policy: &Policy{Thresholds: []Threshold{tt.threshold}}}
+		if _, err := CompileRulesConfig(config); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Threshold %+v: expected error containing %q, got %v", tt.threshold, tt.want, err)
+		}
+	}
+}
+
+func TestPolicyInheritance(t *testing.T) {
+	dir := writeRulesFiles(t, map[string]string{
+		"base.yaml": `
+rules:
+  - id: secret
+    pattern: SECRET
+    severity: high
+policy:
+  thresholds:
+    - verdict: block
+      severity: high
+`,
+		"inherits.yaml":  "extends: base.yaml\nrules: []\n",
+		"overrides.yaml": "extends: base.yaml\nrules: []\npolicy:\n  thresholds:\n    - verdict: quarantine\n      rules: [secret]\n",
+	})
+	for file, want := range map[string]string{"inherits.yaml": VerdictBlock, "overrides.yaml": VerdictQuarantine} {
+		set, err := LoadRuleSet(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("LoadRuleSet(%s) failed: %v", file, err)
+		}
+		if got := set.Scan("SECRET", "doc.txt").Decision.Verdict; got != want {
+			t.Errorf("%s: expected verdict %s, got %s", file, want, got)
+		}
+	}
+}
+
+func TestLintRulesPolicy(t *testing.T) {
+	diags := LintRules([]byte(`rules:
+  - id: secret
+    pattern: SECRET
+    severity: high
+policy:
+  thresholds:
+    - verdict: block
+      rules: [secrt]
+      min_cout: 2
+`), "")
+	if len(diags) != 2 {
+		t.Fatalf("Expected two diagnostics, got %v", diags)
+	}
+	if diags[0].Line != 5 || diags[0].Level != DiagnosticError || !strings.Contains(diags[0].Message, `unknown rule "secrt"`) {
+		t.Errorf("Expected unknown rule error on the policy key, got %v", diags[0])
+	}
+	if diags[1].Line != 9 || diags[1].Level != DiagnosticWarning || !strings.Contains(diags[1].Message, `unknown field "min_cout"`) {
+		t.Errorf("Expected unknown field warning, got %v", diags[1])
+	}
+}
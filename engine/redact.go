@@ -0,0 +1,193 @@
+package engine
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Redaction strategies.
+const (
+	// RedactFixed replaces a match with a fixed mask, "[REDACTED]" by default.
+	RedactFixed = "fixed"
+	// RedactRuleID replaces a match with the ID of the rule that found it,
+	// e.g. "[AWS-KEY]".
+	RedactRuleID = "rule_id"
+	// RedactPartial masks every character of a match except the last Keep,
+	// e.g. "************1234".
+	RedactPartial = "partial"
+	// RedactHash replaces a match with a token derived from its
+	// HMAC-SHA256, so equal values redact to equal tokens.
+	RedactHash = "hash"
+)
+
+const (
+	defaultRedactMask = "[REDACTED]"
+	defaultRedactKeep = 4
+	defaultRedactChar = "*"
+	redactHashLen     = 12
+)
+
+// redactHashKey keys the hash strategy for rulesets without a salt. It is
+// random per process, so tokens cannot be reversed by hashing candidate
+// values, but they only link equal values within one process.
+var redactHashKey = func() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}()
+
+// RedactOptions selects how the matches of a rule are redacted. Rules
+// without options use the ruleset's, and a ruleset without options uses the
+// fixed mask.
+type RedactOptions struct {
+	Strategy string `json:"strategy,omitempty" yaml:"strategy"`
+	// Mask is the replacement for the fixed strategy.
+	Mask string `json:"mask,omitempty" yaml:"mask"`
+	// Keep is the number of trailing characters the partial strategy leaves
+	// visible (4 if unset), and MaskChar the character hiding the rest.
+	Keep     int    `json:"keep,omitempty" yaml:"keep"`
+	MaskChar string `json:"mask_char,omitempty" yaml:"mask_char"`
+	// Salt keys the hash strategy's HMAC-SHA256, so that tokens stay
+	// linkable across processes. It must be kept secret: with it, short
+	// values such as card numbers can be recovered by hashing candidates.
+	// Without it, a random per-process key is used.
+	Salt string `json:"salt,omitempty" yaml:"salt"`
+}
+
+// validateRedact checks a rule's or ruleset's redaction options.
+func validateRedact(o *RedactOptions) error {
+	if o == nil {
+		return nil
+	}
+	switch o.Strategy {
+	case "", RedactFixed, RedactRuleID, RedactPartial, RedactHash:
+	default:
+		return fmt.Errorf("redact: unknown strategy %q", o.Strategy)
+	}
+	if o.Keep < 0 {
+		return fmt.Errorf("redact: keep must not be negative, got %d", o.Keep)
+	}
+	if utf8.RuneCountInString(o.MaskChar) > 1 {
+		return fmt.Errorf("redact: mask_char must be a single character, got %q", o.MaskChar)
+	}
+	return nil
+}
+
+// mask returns the replacement for match, found by ruleID.
+func (o *RedactOptions) mask(ruleID, match string) string {
+	if o == nil {
+		o = &RedactOptions{}
+	}
+	switch o.Strategy {
+	case RedactRuleID:
+		return "[" + strings.ToUpper(ruleID) + "]"
+	case RedactPartial:
+		keep := o.Keep
+		if keep == 0 {
+			keep = defaultRedactKeep
+		}
+		char := o.MaskChar
+		if char == "" {
+			char = defaultRedactChar
+		}
+		runes := []rune(match)
+		hidden := max(len(runes)-keep, 0)
+		if hidden == 0 {
+			hidden = len(runes)
+		}
+		return strings.Repeat(char, hidden) + string(runes[hidden:])
+	case RedactHash:
+		key := redactHashKey
+		if o.Salt != "" {
+			key = []byte(o.Salt)
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(match))
+		return "[" + hex.EncodeToString(mac.Sum(nil))[:redactHashLen] + "]"
+	default:
+		if o.Mask != "" {
+			return o.Mask
+		}
+		return defaultRedactMask
+	}
+}
+
+// redactSpan is a byte range of text to replace and the finding that
+// decides its mask.
+type redactSpan struct {
+	start, end int
+	ruleID     string
+}
+
+// Redact returns text with the matched span of each finding replaced by the
+// mask its rule selects. Composite findings redact the matches that
+// triggered them rather than the text between. Overlapping spans are
+// merged and masked as one, using the rule of the earliest.
+func (s *CompiledRuleSet) Redact(text string, findings []Finding) string {
+	var spans []redactSpan
+	var collect func([]Finding)
+	collect = func(findings []Finding) {
+		for _, f := range findings {
+			if len(f.Contributing) > 0 {
+				collect(f.Contributing)
+				continue
+			}
+			if f.StartOffset < 0 || f.EndOffset > len(text) || f.StartOffset >= f.EndOffset {
+				continue
+			}
+			spans = append(spans, redactSpan{f.StartOffset, f.EndOffset, f.RuleID})
+		}
+	}
+	collect(findings)
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end > spans[j].end
+	})
+
+	var b strings.Builder
+	b.Grow(len(text))
+	pos := 0
+	for i := 0; i < len(spans); {
+		span := spans[i]
+		for i++; i < len(spans) && spans[i].start < span.end; i++ {
+			span.end = max(span.end, spans[i].end)
+		}
+		b.WriteString(text[pos:span.start])
+		b.WriteString(s.redactOptions(span.ruleID).mask(span.ruleID, text[span.start:span.end]))
+		pos = span.end
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// redactOptions returns the redaction options for a rule.
+func (s *CompiledRuleSet) redactOptions(ruleID string) *RedactOptions {
+	if s == nil {
+		return nil
+	}
+	for _, r := range s.rules {
+		if r.ID == ruleID && r.Redact != nil {
+			return r.Redact
+		}
+	}
+	return s.redact
+}
+
+// Redact scans text with the given rules and returns it redacted along with
+// the findings. Each pattern is compiled once per call; rules with invalid
+// patterns are logged and skipped. Prefer CompiledRuleSet.Redact for
+// repeated scans.
+func Redact(text, fileID string, rules []Rule) (string, []Finding) {
+	set, errs := compileRules(rules)
+	logRuleErrors(errs)
+	findings := set.Evaluate(text, fileID)
+	return set.Redact(text, findings), findings
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactStrategies(t *testing.T) {
+	config, err := ParseRulesConfig([]byte(`
+redact:
+  strategy: rule_id
+rules:
+  - id: card
+    pattern: '\d{16}'
+    severity: high
+    redact:
+      strategy: partial
+  - id: password
+    pattern: 'hunter\d'
+    severity: high
+    redact:
+      strategy: fixed
+      mask: '<password>'
+  - id: email
+    pattern: '\w+@example\.com'
+    severity: medium
+    redact:
+      strategy: hash
+  - id: codename
+    pattern: raccoon
+    severity: low
+`))
+	if err != nil {
+		t.Fatalf("ParseRulesConfig failed: %v", err)
+	}
+	set, err := CompileRulesConfig(config)
+	if err != nil {
+		t.Fatalf("CompileRulesConfig failed: %v", err)
+	}
+
+	text := "card 4111111111111111 pw hunter2\nmail bob@example.com about raccoon\n"
+	got := set.Redact(text, set.Evaluate(text, "doc.txt"))
+	token := (&RedactOptions{Strategy: RedactHash}).mask("email", "bob@example.com")
+	want := "card ************1111 pw <password>\nmail " + token + " about [CODENAME]\n"
+	if got != want {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+	if strings.Contains(got, "bob@example.com") {
+		t.Fatal("hashed value leaked")
+	}
+}
+
+func TestRedactMasks(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  *RedactOptions
+		match string
+		want  string
+	}{
+		{"default", nil, "secret", "[REDACTED]"},
+		{"fixed", &RedactOptions{Strategy: RedactFixed, Mask: "XXX"}, "secret", "XXX"},
+		{"rule id", &RedactOptions{Strategy: RedactRuleID}, "secret", "[AWS-KEY]"},
+		{"partial", &RedactOptions{Strategy: RedactPartial}, "123456789", "*****6789"},
+		{"partial keep", &RedactOptions{Strategy: RedactPartial, Keep: 2, MaskChar: "#"}, "123456", "####56"},
+		{"partial short", &RedactOptions{Strategy: RedactPartial}, "1234", "****"},
+		{"partial runes", &RedactOptions{Strategy: RedactPartial, Keep: 1}, "héllo", "****o"},
+		{"hash", &RedactOptions{Strategy: RedactHash, Salt: "pepper"}, "secret", "[804e6d854739]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.mask("aws-key", tt.match); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	// Without a salt the token is keyed per process, so it is stable but is
+	// not the plain SHA-256 of the value.
+	plain := (&RedactOptions{Strategy: RedactHash}).mask("r", "secret")
+	if plain == "[2bb80d537b1d]" {
+		t.Error("Expected an unsalted hash token not to be the value's SHA-256")
+	}
+	if again := (&RedactOptions{Strategy: RedactHash}).mask("r", "secret"); again != plain {
+		t.Errorf("Expected equal values to redact to equal tokens, got %q and %q", plain, again)
+	}
+}
+
+func TestRedactOverlapsAndComposites(t *testing.T) {
+	set, err := CompileRules([]Rule{
+		{ID: "word", Pattern: `secret\w*`, Severity: "high"},
+		{ID: "suffix", Pattern: `sauce`, Severity: "low"},
+		{ID: "key", Pattern: `key=\w+`, Severity: "high"},
+		{ID: "host", Pattern: `host=\w+`, Severity: "high"},
+		{ID: "pair", Type: RuleTypeComposite, Severity: "critical", Composite: &CompositeOptions{All: []string{"key", "host"}, WithinChars: 100}},
+	})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	text := "secretsauce and key=abc then host=db1\n"
+	findings := set.Evaluate(text, "doc.txt")
+	var composite []Finding
+	for _, f := range findings {
+		if f.RuleID == "pair" {
+			composite = append(composite, f)
+		}
+	}
+	if len(composite) != 1 {
+		t.Fatalf("Expected one composite finding, got %+v", findings)
+	}
+	want := "[REDACTED] and [REDACTED] then [REDACTED]\n"
+	if got := set.Redact(text, composite); got != "secretsauce and [REDACTED] then [REDACTED]\n" {
+		t.Errorf("Expected composite to redact its contributing matches, got %q", got)
+	}
+	if got := set.Redact(text, findings); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestRedactInvalidOptions(t *testing.T) {
+	for _, opts := range []*RedactOptions{
+		{Strategy: "blackout"},
+		{Strategy: RedactPartial, Keep: -1},
+		{Strategy: RedactPartial, MaskChar: "ab"},
+	} {
+		if _, err := CompileRules([]Rule{{ID: "r", Pattern: "x", Redact: opts}}); err == nil {
+			t.Errorf("Expected %+v to be rejected", opts)
+		}
+	}
+	if _, err := CompileRulesConfig(&RulesConfig{Redact: &RedactOptions{Strategy: "blackout"}}); err == nil {
+		t.Error("Expected an unknown ruleset strategy to be rejected")
+	}
+}
+
+func TestRedactFunction(t *testing.T) {
+	rules := []Rule{{ID: "ssn", Pattern: `\d{3}-\d{2}-\d{4}`, Severity: "high", Redact: &RedactOptions{Strategy: RedactPartial}}}
+	got, findings := Redact("ssn 123-45-6789", "doc.txt", rules)
+	if got != "ssn *******6789" || len(findings) != 1 {
+		t.Errorf("Expected one finding redacted to last 4, got %q with %d finding(s)", got, len(findings))
+	}
+}
+
+func TestLintRulesRedact(t *testing.T) {
+	diags := LintRules([]byte(`redact:
+  strategy: blackout
+rules:
+  - id: secret
+    pattern: SECRET
+    severity: high
+    redact:
+      strategy: partial
+      keep: -2
+`), "")
+	if len(diags) != 2 {
+		t.Fatalf("Expected two diagnostics, got %v", diags)
+	}
+	if diags[0].Line != 1 || !strings.Contains(diags[0].Message, `unknown strategy "blackout"`) {
+		t.Errorf("Expected unknown strategy error on the redact key, got %v", diags[0])
+	}
+	if diags[1].RuleID != "secret" || !strings.Contains(diags[1].Message, "keep must not be negative") {
+		t.Errorf("Expected negative keep error for the rule, got %v", diags[1])
+	}
+}
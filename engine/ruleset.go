@@ -0,0 +1,587 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// matcher locates candidate matches for a rule. *regexp.Regexp satisfies it.
+type matcher interface {
+	FindAllStringIndex(s string, n int) [][]int
+}
+
+// compiledRule pairs a rule with its precompiled matcher.
+type compiledRule struct {
+	rule        Rule
+	m           matcher
+	constraints *matchConstraints
+	validate    Validator
+	allowlist   *compiledAllowlist
+}
+
+// find returns the byte offsets of matches in text that satisfy the rule's
+// lookaround constraints and validator. Empty matches are ignored since they
+// carry no matched text to report.
+func (cr compiledRule) find(text string) [][]int {
+	var locs [][]int
+	for _, loc := range cr.m.FindAllStringIndex(text, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		if !cr.constraints.allows(text, loc[0], loc[1]) {
+			continue
+		}
+		if cr.validate != nil && !cr.validate(text[loc[0]:loc[1]]) {
+			continue
+		}
+		locs = append(locs, loc)
+	}
+	return locs
+}
+
+// CompiledRuleSet is an immutable set of rules whose patterns have been
+// compiled once at load time. It is safe for concurrent use.
+type CompiledRuleSet struct {
+	rules      []Rule
+	compiled   []compiledRule
+	composites []compiledRule
+	allowlist  *compiledAllowlist
+	policy     *compiledPolicy
+	redact     *RedactOptions
+	// rank orders findings on the same line: line-scoped rules, then other
+	// rules, then composites, each in rule order.
+	rank map[string]int
+	// noInline disables inline suppression directives in scanned text.
+	noInline bool
+}
+
+// Result holds the outcome of scanning a document. Suppressed lists the
+// findings that matched but were dropped by an allowlist or an inline
+// directive, and Suppressions the inline directives found in the text.
+// Decision is the ruleset policy's verdict on Findings. Truncated is set when
+// the scan hit a limit and findings were dropped.
+type Result struct {
+	Findings     []Finding
+	Suppressed   []Finding
+	Suppressions []Suppression
+	Decision     Decision
+	Truncated    bool
+}
+
+// RuleError describes a rule that could not be compiled.
+type RuleError struct {
+	RuleID  string
+	Pattern string
+	Err     error
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("rule %s: %v", e.RuleID, e.Err)
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// CompileError reports every rule in a set that failed to compile.
+type CompileError struct {
+	Errors []*RuleError
+}
+
+func (e *CompileError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d invalid rule(s): %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// CompileRules compiles every rule pattern and returns an error describing
+// all rules that failed to compile.
+func CompileRules(rules []Rule) (*CompiledRuleSet, error) {
+	set, errs := compileRules(rules)
+	if len(errs) > 0 {
+		return nil, &CompileError{Errors: errs}
+	}
+	return set, nil
+}
+
+// CompileRulesConfig compiles the rules of a configuration together with its
+// ruleset-wide allowlist and policy.
+func CompileRulesConfig(config *RulesConfig) (*CompiledRuleSet, error) {
+	if config.Extends != "" || len(config.Include) > 0 {
+		return nil, fmt.Errorf("extends and include must be resolved by loading the rules file")
+	}
+	allowlist, err := compileAllowlist(config.allowlist())
+	if err != nil {
+		return nil, err
+	}
+	set, err := CompileRules(config.Rules)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(set.rules))
+	for _, r := range set.rules {
+		known[r.ID] = true
+	}
+	if set.policy, err = compilePolicy(config.Policy, known); err != nil {
+		return nil, err
+	}
+	if err := validateRedact(config.Redact); err != nil {
+		return nil, err
+	}
+	set.redact = config.Redact
+	set.allowlist = allowlist
+	set.noInline = config.DisableInlineSuppressions
+	return set, nil
+}
+
+// compileRules compiles the rules that are valid and returns errors for the
+// rest. The returned set contains only the valid rules.
+func compileRules(rules []Rule) (*CompiledRuleSet, []*RuleError) {
+	set := &CompiledRuleSet{
+		rules:    append([]Rule(nil), rules...),
+		compiled: make([]compiledRule, 0, len(rules)),
+	}
+	var errs []*RuleError
+	available := make(map[string]bool)
+	var composites []compiledRule
+	for i := range set.rules {
+		set.rules[i].normalize()
+		cr, err := compileRule(set.rules[i])
+		if err != nil {
+			errs = append(errs, &RuleError{RuleID: set.rules[i].ID, Pattern: set.rules[i].Pattern, Err: err})
+			continue
+		}
+		if cr.rule.Type == RuleTypeComposite {
+			composites = append(composites, cr)
+			continue
+		}
+		available[cr.rule.ID] = true
+		set.compiled = append(set.compiled, cr)
+	}
+	for _, cr := range composites {
+		if err := checkCompositeRefs(cr.rule, available); err != nil {
+			errs = append(errs, &RuleError{RuleID: cr.rule.ID, Err: err})
+			continue
+		}
+		set.composites = append(set.composites, cr)
+	}
+	set.rank = rankRules(set)
+	return set, errs
+}
+
+// rankRules ranks the rules of a set in the order their findings on the same
+// line are reported.
+func rankRules(set *CompiledRuleSet) map[string]int {
+	var line, others []compiledRule
+	for _, cr := range set.compiled {
+		if cr.rule.Scope == ScopeLine {
+			line = append(line, cr)
+		} else {
+			others = append(others, cr)
+		}
+	}
+	rank := make(map[string]int)
+	for _, cr := range slices.Concat(line, others, set.composites) {
+		if _, ok := rank[cr.rule.ID]; !ok {
+			rank[cr.rule.ID] = len(rank)
+		}
+	}
+	return rank
+}
+
+// compileRule builds the matcher, constraints and validator for a single rule.
+func compileRule(rule Rule) (compiledRule, error) {
+	cr := compiledRule{rule: rule}
+	var err error
+	if cr.allowlist, err = compileAllowlist(rule.Allowlist); err != nil {
+		return cr, err
+	}
+	if err := validateRedact(rule.Redact); err != nil {
+		return cr, err
+	}
+	switch rule.Type {
+	case RuleTypeRegex:
+		if cr.m, err = regexp.Compile(rule.Pattern); err != nil {
+			return cr, err
+		}
+	case RuleTypeEntropy:
+		if cr.m, err = newEntropyMatcher(rule); err != nil {
+			return cr, err
+		}
+	case RuleTypeDictionary:
+		if cr.m, err = newDictionaryMatcher(rule); err != nil {
+			return cr, err
+		}
+	case RuleTypeComposite:
+		return cr, validateComposite(rule)
+	default:
+		return cr, fmt.Errorf("unsupported rule type %q", rule.Type)
+	}
+	if err := validateScope(rule); err != nil {
+		return cr, err
+	}
+	if rule.MaxMatches < 0 {
+		return cr, fmt.Errorf("max_matches must not be negative, got %d", rule.MaxMatches)
+	}
+	if cr.constraints, err = compileConstraints(rule); err != nil {
+		return cr, err
+	}
+	if rule.Validator != "" {
+		if cr.validate, err = lookupValidator(rule.Validator); err != nil {
+			return cr, err
+		}
+	}
+	return cr, nil
+}
+
+// Rules returns a copy of the rules in the set.
+func (s *CompiledRuleSet) Rules() []Rule {
+	if s == nil {
+		return nil
+	}
+	return append([]Rule(nil), s.rules...)
+}
+
+// Len returns the number of compiled rules in the set.
+func (s *CompiledRuleSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.compiled) + len(s.composites)
+}
+
+// Evaluate scans the provided text and returns one finding per match for the
+// rules in the set, up to the configured Limits. Findings are ordered by
+// starting line.
+func (s *CompiledRuleSet) Evaluate(text, fileID string) []Finding {
+	return s.Scan(text, fileID).Findings
+}
+
+// Scan evaluates the rules in the set against text like Evaluate, and also
+// reports the findings suppressed by inline directives and allowlists.
+func (s *CompiledRuleSet) Scan(text, fileID string) *Result {
+	result, _ := s.ScanContext(context.Background(), text, fileID)
+	return result
+}
+
+// ScanContext is Scan with a context. The scan stops early and returns the
+// context's error if it is cancelled or its deadline passes.
+func (s *CompiledRuleSet) ScanContext(ctx context.Context, text, fileID string) (*Result, error) {
+	if s == nil {
+		return &Result{Decision: s.decide(nil)}, nil
+	}
+	doc := newDocument(text)
+	sc := s.newScan(ctx, fileID)
+	sc.pageBreaks = pageBreaks(text, 0)
+	sc.inline.parse(doc)
+	tasks := sc.lineTasks(doc)
+	for _, cr := range s.compiled {
+		if cr.rule.Scope == ScopeLine {
+			continue
+		}
+		tasks = append(tasks, ruleTask{cr: cr, doc: doc, run: func() []Finding {
+			return sc.matchSegments(cr, doc, doc.segments(cr.rule), make(map[[2]int]bool))
+		}})
+	}
+	if err := sc.run(tasks); err != nil {
+		return nil, err
+	}
+	sc.scanComposites(doc)
+	return sc.finish(s.sortFindings), nil
+}
+
+// ctxCheckInterval is how many lines or segments a task matches between
+// checks for cancellation.
+const ctxCheckInterval = 256
+
+// scan accumulates the findings of one document. Documents read from a
+// stream are scanned in pieces, each a document of its own.
+type scan struct {
+	ctx          context.Context
+	set          *CompiledRuleSet
+	fileID       string
+	contextChars int
+	limits       Limits
+	matches      map[string]int
+	inline       *inlineSuppressions
+	result       *Result
+	// pageBreaks are the offsets of the page breaks seen so far.
+	pageBreaks []int
+}
+
+func (s *CompiledRuleSet) newScan(ctx context.Context, fileID string) *scan {
+	sc := &scan{
+		ctx:          ctx,
+		set:          s,
+		fileID:       fileID,
+		contextChars: GetContextChars(),
+		limits:       GetLimits(),
+		matches:      make(map[string]int),
+		result:       &Result{},
+	}
+	if !s.noInline {
+		sc.inline = &inlineSuppressions{byLine: make(map[int][]int)}
+	}
+	return sc
+}
+
+// suppression returns what drops a finding, an inline directive or an
+// allowlist, or "" if nothing does. It does not record the directive's use,
+// so tasks may call it concurrently.
+func (sc *scan) suppression(cr compiledRule, f Finding, doc *document) string {
+	if idx := sc.inline.lookup(f); idx >= 0 {
+		return "inline:" + sc.inline.list[idx].Directive
+	}
+	if reason, ok := sc.set.suppresses(cr, f, doc); ok {
+		return reason
+	}
+	return ""
+}
+
+// add records a finding classified by suppression. Only findings that are
+// not suppressed count against the rule's cap; matches beyond it are
+// dropped.
+func (sc *scan) add(cr compiledRule, f Finding) {
+	if f.SuppressedBy != "" {
+		if strings.HasPrefix(f.SuppressedBy, "inline:") {
+			sc.inline.record(f)
+		}
+		sc.result.Suppressed = append(sc.result.Suppressed, f)
+		return
+	}
+	if n := sc.limits.matchCap(cr.rule); n > 0 {
+		if sc.matches[cr.rule.ID] >= n {
+			sc.result.Truncated = true
+			return
+		}
+		sc.matches[cr.rule.ID]++
+	}
+	sc.result.Findings = append(sc.result.Findings, f)
+}
+
+// taskFindings collects the findings of a task, classified by suppression.
+// Findings and suppressed findings count against separate caps, so that
+// allowlisted or ignored matches do not use up the budget of the findings
+// after them.
+type taskFindings struct {
+	findings               []Finding
+	kept, suppressed       int
+	keptCap, suppressedCap int
+}
+
+func (sc *scan) newTaskFindings(cr compiledRule) *taskFindings {
+	return &taskFindings{keptCap: sc.limits.taskCap(cr.rule), suppressedCap: sc.limits.suppressedCap()}
+}
+
+// collect classifies and collects a finding, and reports whether the task
+// should go on matching.
+func (sc *scan) collect(t *taskFindings, cr compiledRule, f Finding, doc *document) bool {
+	if f.SuppressedBy = sc.suppression(cr, f, doc); f.SuppressedBy != "" {
+		if t.suppressedCap == 0 || t.suppressed < t.suppressedCap {
+			t.suppressed++
+			t.findings = append(t.findings, f)
+		}
+		return true
+	}
+	t.kept++
+	t.findings = append(t.findings, f)
+	return t.keptCap == 0 || t.kept < t.keptCap
+}
+
+// lineTasks splits the evaluation of the line-scoped rules against doc into
+// tasks of at most parallelChunkLines lines each.
+func (sc *scan) lineTasks(doc *document) []ruleTask {
+	var tasks []ruleTask
+	for _, cr := range sc.set.compiled {
+		if cr.rule.Scope != ScopeLine {
+			continue
+		}
+		for lo := 0; lo < len(doc.lines); lo += parallelChunkLines {
+			hi := min(lo+parallelChunkLines, len(doc.lines))
+			tasks = append(tasks, ruleTask{cr: cr, doc: doc, run: func() []Finding {
+				return sc.matchLines(cr, doc, lo, hi)
+			}})
+		}
+	}
+	return tasks
+}
+
+// matchLines returns the findings of a line-scoped rule on the lines of doc
+// with indexes lo through hi-1.
+func (sc *scan) matchLines(cr compiledRule, doc *document, lo, hi int) []Finding {
+	t := sc.newTaskFindings(cr)
+	for i := lo; i < hi; i++ {
+		if (i-lo)%ctxCheckInterval == 0 && sc.ctx.Err() != nil {
+			return t.findings
+		}
+		for _, loc := range cr.find(doc.lines[i]) {
+			f := cr.newFinding(sc.fileID, doc, doc.lineStarts[i], loc, sc.contextChars)
+			if !sc.collect(t, cr, f, doc) {
+				return t.findings
+			}
+		}
+	}
+	return t.findings
+}
+
+// matchSegments returns the findings of a rule on segments of doc, skipping
+// matches already found in an overlapping segment.
+func (sc *scan) matchSegments(cr compiledRule, doc *document, segs []segment, seen map[[2]int]bool) []Finding {
+	t := sc.newTaskFindings(cr)
+	for i, seg := range segs {
+		if i%ctxCheckInterval == 0 && sc.ctx.Err() != nil {
+			return t.findings
+		}
+		for _, loc := range cr.find(seg.text) {
+			key := [2]int{seg.offset + loc[0], seg.offset + loc[1]}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			f := cr.newFinding(sc.fileID, doc, seg.offset, loc, sc.contextChars)
+			if !sc.collect(t, cr, f, doc) {
+				return t.findings
+			}
+		}
+	}
+	return t.findings
+}
+
+// run evaluates tasks concurrently and adds their findings in task order.
+// It returns the context's error if the scan was cancelled.
+func (sc *scan) run(tasks []ruleTask) error {
+	results := runTasks(tasks)
+	if err := sc.ctx.Err(); err != nil {
+		return err
+	}
+	for i, findings := range results {
+		for _, f := range findings {
+			sc.add(tasks[i].cr, f)
+		}
+	}
+	return nil
+}
+
+// scanComposites evaluates the composite rules over the findings so far.
+func (sc *scan) scanComposites(doc *document) {
+	if len(sc.set.composites) == 0 {
+		return
+	}
+	byRule := make(map[string][]Finding)
+	for _, f := range sc.result.Findings {
+		byRule[f.RuleID] = append(byRule[f.RuleID], f)
+	}
+	for _, cr := range sc.set.composites {
+		for _, f := range evaluateComposite(cr, byRule, sc.fileID, doc, sc.contextChars) {
+			f.SuppressedBy = sc.suppression(cr, f, doc)
+			sc.add(cr, f)
+		}
+	}
+}
+
+// finish orders the findings, applies the result cap and completes the
+// result.
+func (sc *scan) finish(sort func([]Finding)) *Result {
+	sort(sc.result.Findings)
+	sort(sc.result.Suppressed)
+	var findingsCut, suppressedCut bool
+	sc.result.Findings, findingsCut = sc.limits.truncate(sc.result.Findings)
+	sc.result.Suppressed, suppressedCut = sc.limits.truncate(sc.result.Suppressed)
+	sc.result.Truncated = sc.result.Truncated || findingsCut || suppressedCut
+	setPages(sc.result.Findings, sc.pageBreaks)
+	setPages(sc.result.Suppressed, sc.pageBreaks)
+	sc.result.Suppressions = sc.inline.report()
+	sc.result.Decision = sc.set.decide(sc.result.Findings)
+	return sc.result
+}
+
+// decide applies the ruleset policy to a document's findings.
+func (s *CompiledRuleSet) decide(findings []Finding) Decision {
+	if s == nil {
+		return (*compiledPolicy)(nil).decide(findings)
+	}
+	return s.policy.decide(findings)
+}
+
+// suppresses checks a finding against its rule's allowlist and then the
+// ruleset allowlist, returning which one matched and how.
+func (s *CompiledRuleSet) suppresses(cr compiledRule, f Finding, doc *document) (string, bool) {
+	if reason, ok := cr.allowlist.suppresses(f, doc); ok {
+		return "rule:" + reason, true
+	}
+	if reason, ok := s.allowlist.suppresses(f, doc); ok {
+		return "global:" + reason, true
+	}
+	return "", false
+}
+
+// sortFindings orders findings by starting line, then by rule rank, then by
+// starting offset, so that Scan and ScanReader report the same order.
+func (s *CompiledRuleSet) sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if ra, rb := s.rank[a.RuleID], s.rank[b.RuleID]; ra != rb {
+			return ra < rb
+		}
+		return a.StartOffset < b.StartOffset
+	})
+}
+
+// newFinding builds a finding for a match located at loc within the segment
+// starting at offset. Columns are 1-based byte columns; EndColumn is exclusive.
+func (cr compiledRule) newFinding(fileID string, doc *document, offset int, loc []int, contextChars int) Finding {
+	rule := cr.rule
+	start, end := offset+loc[0], offset+loc[1]
+	startLine := doc.lineAt(start)
+	endLine := startLine
+	if end > start {
+		endLine = doc.lineAt(end - 1)
+	}
+	if rule.ContextChars != 0 {
+		contextChars = rule.ContextChars
+	}
+	f := Finding{
+		FileID:      fileID,
+		RuleID:      rule.ID,
+		Severity:    rule.Severity,
+		Line:        startLine,
+		Context:     contextWindow(doc, start, end, startLine, endLine, contextChars),
+		Description: rule.Description,
+		Category:    rule.Category,
+		Tags:        rule.Tags,
+		Match:       doc.slice(start, end),
+		EndLine:     endLine,
+		Column:      start - doc.lineStart(startLine) + 1,
+		EndColumn:   end - doc.lineStart(endLine) + 1,
+		StartOffset: start,
+		EndOffset:   end,
+		Validator:   rule.Validator,
+		Validated:   rule.Validator != "",
+	}
+	if dict, ok := cr.m.(*dictionaryMatcher); ok && len(loc) > 2 {
+		f.Term = dict.terms[loc[2]]
+	}
+	return f
+}
+
+// logRuleErrors logs rules that were skipped because they failed to compile.
+func logRuleErrors(errs []*RuleError) {
+	for _, err := range errs {
+		logrus.WithFields(logrus.Fields{
+			"rule_id": err.RuleID,
+			"pattern": err.Pattern,
+			"error":   err.Err,
+		}).Warn("Failed to compile regex for rule")
+	}
+}
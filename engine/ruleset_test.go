@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCompileRulesRejectsBadPattern(t *testing.T) {
+	rules := []Rule{
+		{ID: "good", Pattern: "test", Severity: "low"},
+		{ID: "bad", Pattern: "[", Severity: "high"},
+	}
+
+	set, err := CompileRules(rules)
+	if err == nil {
+		t.Fatalf("Expected error for invalid pattern")
+	}
+	if set != nil {
+		t.Errorf("Expected nil rule set on error")
+	}
+
+	var compileErr *CompileError
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("Expected CompileError, got %T", err)
+	}
+	if len(compileErr.Errors) != 1 || compileErr.Errors[0].RuleID != "bad" {
+		t.Errorf("Expected a single error for rule 'bad', got %v", compileErr.Errors)
+	}
+}
+
+func TestCompiledRuleSetEvaluate(t *testing.T) {
+	set, err := CompileRules([]Rule{
+		{ID: "test-rule", Pattern: "error", Severity: "high", Description: "Error pattern"},
+	})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+
+	findings := set.Evaluate("ok\nan error here\nok", "test.txt")
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %d", len(findings))
+	}
+	if findings[0].Line != 2 || findings[0].RuleID != "test-rule" {
+		t.Errorf("Unexpected finding: %+v", findings[0])
+	}
+}
+
+func TestScanOrdersFindingsWithinLine(t *testing.T) {
+	set, err := CompileRules([]Rule{
+		{ID: "caveat", Pattern: `BEGIN.*END`, Scope: ScopeDocument},
+		{ID: "both", Type: RuleTypeComposite, Composite: &CompositeOptions{All: []string{"caveat", "secret"}}},
+		{ID: "secret", Pattern: "SECRET"},
+	})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	text := "BEGIN SECRET END SECRET"
+	var got []string
+	for _, f := range set.Evaluate(text, "doc.txt") {
+		got = append(got, fmt.Sprintf("%s@%d", f.RuleID, f.StartOffset))
+	}
+	if want := []string{"secret@6", "secret@17", "caveat@0", "both@0"}; !slices.Equal(got, want) {
+		t.Errorf("Expected line rules, then other rules, then composites, got %v", got)
+	}
+
+	streamed, err := set.ScanReader(context.Background(), strings.NewReader(text), "doc.txt")
+	if err != nil {
+		t.Fatalf("ScanReader failed: %v", err)
+	}
+	if !reflect.DeepEqual(streamed.Findings, set.Scan(text, "doc.txt").Findings) {
+		t.Errorf("Expected ScanReader to order findings like Scan, got %+v", streamed.Findings)
+	}
+}
+
+func TestSetRulesKeepsCurrentSetOnError(t *testing.T) {
+	if err := SetRules([]Rule{{ID: "keep", Pattern: "keep", Severity: "low"}}); err != nil {
+		t.Fatalf("SetRules failed: %v", err)
+	}
+
+	if err := SetRules([]Rule{{ID: "bad", Pattern: "(", Severity: "low"}}); err == nil {
+		t.Fatalf("Expected error for invalid pattern")
+	}
+
+	rules := GetRules()
+	if len(rules) != 1 || rules[0].ID != "keep" {
+		t.Errorf("Expected previous rule set to be kept, got %v", rules)
+	}
+}
+
+func TestConcurrentReloadAndEvaluate(t *testing.T) {
+	if err := SetRules([]Rule{{ID: "a", Pattern: "alpha", Severity: "low"}}); err != nil {
+		t.Fatalf("SetRules failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetRules([]Rule{{ID: "b", Pattern: "beta", Severity: "low"}})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				GetRuleSet().Evaluate("alpha beta", "race.txt")
+			}
+		}()
+	}
+	wg.Wait()
+}
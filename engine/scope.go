@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rule scopes control how much text a pattern is matched against at once.
+const (
+	ScopeLine      = "line"
+	ScopeWindow    = "window"
+	ScopeParagraph = "paragraph"
+	ScopeDocument  = "document"
+)
+
+// defaultWindowLines is used for window-scoped rules that do not set window.
+const defaultWindowLines = 5
+
+// validateScope checks the scope options of a rule.
+func validateScope(rule Rule) error {
+	switch rule.Scope {
+	case ScopeLine, ScopeParagraph, ScopeDocument:
+		return nil
+	case ScopeWindow:
+		if rule.Window < 0 {
+			return fmt.Errorf("window must be positive, got %d", rule.Window)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported scope %q", rule.Scope)
+	}
+}
+
+// document indexes the lines of a scanned text so that byte offsets can be
+// mapped back to line numbers. A document may also hold a run of lines from
+// a longer stream, in which case first is the line number of its first line
+// and base the offset of text within the stream. Line numbers and offsets
+// passed to and returned by its methods are always those of the stream.
+type document struct {
+	text       string
+	lines      []string
+	lineStarts []int
+	first      int
+	base       int
+}
+
+func newDocument(text string) *document {
+	return newDocumentAt(text, 1, 0)
+}
+
+// newDocumentAt indexes text that starts on line first at offset base.
+func newDocumentAt(text string, first, base int) *document {
+	lines := strings.Split(text, "\n")
+	starts := make([]int, len(lines))
+	offset := base
+	for i, line := range lines {
+		starts[i] = offset
+		offset += len(line) + 1
+	}
+	return &document{text: text, lines: lines, lineStarts: starts, first: first, base: base}
+}
+
+// lineAt returns the 1-based line number containing the byte offset.
+func (d *document) lineAt(offset int) int {
+	return d.first - 1 + sort.Search(len(d.lineStarts), func(i int) bool {
+		return d.lineStarts[i] > offset
+	})
+}
+
+// lineStart returns the byte offset of the first character of a 1-based line.
+func (d *document) lineStart(line int) int {
+	return d.lineStarts[line-d.first]
+}
+
+// lineEnd returns the byte offset just past the last character of a 1-based line.
+func (d *document) lineEnd(line int) int {
+	return d.lineStarts[line-d.first] + len(d.lines[line-d.first])
+}
+
+// slice returns the text between two byte offsets.
+func (d *document) slice(start, end int) string {
+	return d.text[start-d.base : end-d.base]
+}
+
+// segment is a contiguous slice of the document that a rule is matched against.
+type segment struct {
+	text   string
+	offset int
+}
+
+// segments splits the document according to a rule's scope. Window segments
+// overlap so that matches crossing a window boundary are still found; callers
+// deduplicate by offset.
+func (d *document) segments(rule Rule) []segment {
+	switch rule.Scope {
+	case ScopeDocument:
+		return []segment{{text: d.text, offset: d.base}}
+	case ScopeParagraph:
+		return d.paragraphs()
+	case ScopeWindow:
+		return d.windows(windowSize(rule))
+	default:
+		segs := make([]segment, len(d.lines))
+		for i, line := range d.lines {
+			segs[i] = segment{text: line, offset: d.lineStarts[i]}
+		}
+		return segs
+	}
+}
+
+// windowSize returns the number of lines in a window-scoped rule's windows.
+func windowSize(rule Rule) int {
+	if rule.Window == 0 {
+		return defaultWindowLines
+	}
+	return rule.Window
+}
+
+// windows returns a segment of size lines starting at every line.
+func (d *document) windows(size int) []segment {
+	if size >= len(d.lines) {
+		return []segment{{text: d.text, offset: d.base}}
+	}
+	segs := make([]segment, 0, len(d.lines)-size+1)
+	for i := 0; i+size <= len(d.lines); i++ {
+		start := d.lineStarts[i]
+		end := d.lineEnd(d.first + i + size - 1)
+		segs = append(segs, segment{text: d.slice(start, end), offset: start})
+	}
+	return segs
+}
+
+// paragraphs returns runs of lines separated by blank lines.
+func (d *document) paragraphs() []segment {
+	var segs []segment
+	first := -1
+	for i, line := range d.lines {
+		blank := strings.TrimSpace(line) == ""
+		if !blank && first < 0 {
+			first = i
+		}
+		if blank && first >= 0 {
+			segs = append(segs, d.span(first, i-1))
+			first = -1
+		}
+	}
+	if first >= 0 {
+		segs = append(segs, d.span(first, len(d.lines)-1))
+	}
+	return segs
+}
+
+// span returns the segment covering the 0-based lines first through last.
+func (d *document) span(first, last int) segment {
+	start := d.lineStarts[first]
+	return segment{text: d.slice(start, d.lineEnd(d.first+last)), offset: start}
+}
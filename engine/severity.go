@@ -0,0 +1,133 @@
+package engine
+
+import "strings"
+
+// Severity is a canonical finding severity. Higher values are more severe;
+// SeverityUnknown is used for severities outside the vocabulary.
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityInformational
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = [...]string{
+	SeverityUnknown:       "unknown",
+	SeverityInformational: "informational",
+	SeverityLow:           "low",
+	SeverityMedium:        "medium",
+	SeverityHigh:          "high",
+	SeverityCritical:      "critical",
+}
+
+// severityScores weight each severity in a document's risk score, following
+// the lower bounds of the CVSS rating bands.
+var severityScores = [...]int{
+	SeverityUnknown:       0,
+	SeverityInformational: 0,
+	SeverityLow:           1,
+	SeverityMedium:        4,
+	SeverityHigh:          7,
+	SeverityCritical:      10,
+}
+
+// severityAliases maps accepted spellings, lowercased, to severities.
+var severityAliases = map[string]Severity{
+	"informational": SeverityInformational,
+	"information":   SeverityInformational,
+	"info":          SeverityInformational,
+	"low":           SeverityLow,
+	"minor":         SeverityLow,
+	"medium":        SeverityMedium,
+	"moderate":      SeverityMedium,
+	"med":           SeverityMedium,
+	"high":          SeverityHigh,
+	"major":         SeverityHigh,
+	"critical":      SeverityCritical,
+	"crit":          SeverityCritical,
+	"severe":        SeverityCritical,
+}
+
+// maxRiskScore caps the aggregate risk score of a document.
+const maxRiskScore = 100
+
+// ParseSeverity returns the severity named by s, ignoring case and
+// surrounding space. It reports false for names outside the vocabulary.
+func ParseSeverity(s string) (Severity, bool) {
+	sev, ok := severityAliases[strings.ToLower(strings.TrimSpace(s))]
+	return sev, ok
+}
+
+// String returns the canonical name of the severity.
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return severityNames[SeverityUnknown]
+	}
+	return severityNames[s]
+}
+
+// Score returns the weight of the severity in a document's risk score.
+func (s Severity) Score() int {
+	if s < 0 || int(s) >= len(severityScores) {
+		return 0
+	}
+	return severityScores[s]
+}
+
+// normalizeSeverity returns the canonical name for a known severity and
+// leaves unknown ones unchanged.
+func normalizeSeverity(s string) string {
+	if sev, ok := ParseSeverity(s); ok {
+		return sev.String()
+	}
+	return s
+}
+
+// Level returns the canonical severity of the finding.
+func (f Finding) Level() Severity {
+	sev, _ := ParseSeverity(f.Severity)
+	return sev
+}
+
+// FilterSeverity returns the findings at or above min. Findings with an
+// unknown severity are only kept when min is SeverityUnknown.
+func FilterSeverity(findings []Finding, min Severity) []Finding {
+	if min == SeverityUnknown {
+		return findings
+	}
+	filtered := make([]Finding, 0, len(findings))
+	for _, f := range findings {
+		if f.Level() >= min {
+			filtered = append(filtered, f)
+		}
+	}
+	return filtered
+}
+
+// RiskScore sums the severity scores of the findings into a document risk
+// score between 0 and 100.
+func RiskScore(findings []Finding) int {
+	score := 0
+	for _, f := range findings {
+		score += f.Level().Score()
+		if score >= maxRiskScore {
+			return maxRiskScore
+		}
+	}
+	return score
+}
+
+// MaxSeverity returns the highest severity among the findings.
+func MaxSeverity(findings []Finding) Severity {
+	max := SeverityUnknown
+	for _, f := range findings {
+		if l := f.Level(); l > max {
+			max = l
+		}
+	}
+	return max
+}
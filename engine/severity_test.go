@@ -0,0 +1,86 @@
+package engine
+
+import "testing"
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Severity
+		ok   bool
+	}{
+		{"CRITICAL", SeverityCritical, true},
+		{" High ", SeverityHigh, true},
+		{"moderate", SeverityMedium, true},
+		{"minor", SeverityLow, true},
+		{"info", SeverityInformational, true},
+		{"informational", SeverityInformational, true},
+		{"spicy", SeverityUnknown, false},
+		{"", SeverityUnknown, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseSeverity(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseSeverity(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+	if !(SeverityCritical > SeverityHigh && SeverityHigh > SeverityMedium && SeverityMedium > SeverityLow && SeverityLow > SeverityInformational) {
+		t.Error("Severities are not ordered")
+	}
+}
+
+func TestRuleSeverityNormalizedAtLoad(t *testing.T) {
+	rules, err := LoadRulesFromFile("../config/rules.yaml")
+	if err != nil {
+		t.Fatalf("LoadRulesFromFile failed: %v", err)
+	}
+	for _, r := range rules {
+		if _, ok := ParseSeverity(r.Severity); !ok || r.Severity != normalizeSeverity(r.Severity) {
+			t.Errorf("Rule %s has non-canonical severity %q", r.ID, r.Severity)
+		}
+	}
+
+	findings := Evaluate("top secret and a note", "doc.txt", []Rule{
+		{ID: "ts", Pattern: "top secret", Severity: "CRITICAL"},
+		{ID: "note", Pattern: "note", Severity: "Info"},
+		{ID: "odd", Pattern: "and", Severity: "spicy"},
+	})
+	want := map[string]string{"ts": "critical", "note": "informational", "odd": "spicy"}
+	for _, f := range findings {
+		if f.Severity != want[f.RuleID] {
+			t.Errorf("Rule %s: expected severity %q, got %q", f.RuleID, want[f.RuleID], f.Severity)
+		}
+	}
+}
+
+func TestFilterSeverityAndRiskScore(t *testing.T) {
+	findings := []Finding{
+		{RuleID: "a", Severity: "critical"},
+		{RuleID: "b", Severity: "high"},
+		{RuleID: "c", Severity: "medium"},
+		{RuleID: "d", Severity: "low"},
+		{RuleID: "e", Severity: "informational"},
+		{RuleID: "f", Severity: "spicy"},
+	}
+	if got := FilterSeverity(findings, SeverityUnknown); len(got) != 6 {
+		t.Errorf("Expected no filtering without a minimum, got %d findings", len(got))
+	}
+	if got := FilterSeverity(findings, SeverityHigh); len(got) != 2 || got[0].RuleID != "a" || got[1].RuleID != "b" {
+		t.Errorf("Expected critical and high findings, got %+v", got)
+	}
+	if got := RiskScore(findings); got != 22 {
+		t.Errorf("Expected risk score 22, got %d", got)
+	}
+	many := make([]Finding, 20)
+	for i := range many {
+		many[i].Severity = "critical"
+	}
+	if got := RiskScore(many); got != 100 {
+		t.Errorf("Expected risk score capped at 100, got %d", got)
+	}
+	if got := MaxSeverity(findings); got != SeverityCritical {
+		t.Errorf("Expected max severity critical, got %v", got)
+	}
+	if got := MaxSeverity(nil); got != SeverityUnknown {
+		t.Errorf("Expected unknown max severity for no findings, got %v", got)
+	}
+}
@@ -0,0 +1,258 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+)
+
+// ScanReader evaluates the rules in the set against text read from r, like
+// Scan, without holding the whole text in memory. Lines are read one at a
+// time and multi-line rules see the lines carried over for them: the last
+// Window lines for window-scoped rules and the current paragraph for
+// paragraph-scoped rules. Document-scoped and composite rules need the whole
+// text, so a set containing them keeps it until the end of the stream.
+//
+// The scan stops and returns the context's error if it is cancelled or its
+// deadline passes. Once more findings than the result cap have been found,
+// the rest of the stream is not read and the result is marked Truncated.
+func (s *CompiledRuleSet) ScanReader(ctx context.Context, r io.Reader, fileID string) (*Result, error) {
+	if s == nil {
+		return &Result{Decision: s.decide(nil)}, nil
+	}
+	st := s.newStream(ctx, fileID)
+	br := bufio.NewReader(r)
+	for !st.full() {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if err == io.EOF {
+			st.push(line)
+			break
+		}
+		st.push(line[:len(line)-1])
+	}
+	result := st.finish()
+	if st.err != nil {
+		return nil, st.err
+	}
+	return result, nil
+}
+
+// EvaluateReader is the streaming counterpart of Evaluate.
+func (s *CompiledRuleSet) EvaluateReader(ctx context.Context, r io.Reader, fileID string) ([]Finding, error) {
+	result, err := s.ScanReader(ctx, r, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return result.Findings, nil
+}
+
+// streamBatchBytes is the amount of text read from a stream before it is
+// evaluated, so that line-scoped rules can be matched against a batch of
+// lines in parallel.
+const streamBatchBytes = 256 << 10
+
+// windowRule is a window-scoped rule being evaluated over a stream.
+type windowRule struct {
+	cr   compiledRule
+	size int
+	seen map[[2]int]bool
+}
+
+// stream scans a document in batches of lines. Lines are numbered and
+// offsets counted as if the whole text had been passed to Scan.
+type stream struct {
+	*scan
+	lines  int // lines read so far
+	offset int // offset of the next line
+
+	batch      []string
+	batchBytes int
+
+	windows   []windowRule
+	maxWindow int
+	recent    []string // the last maxWindow lines before the batch
+	starts    []int    // offsets of the recent lines
+
+	paragraphs []compiledRule
+	para       []string
+	paraFirst  int
+	paraStart  int
+
+	documents []compiledRule
+	keepAll   bool
+	all       strings.Builder
+
+	// err is set when the scan is cancelled.
+	err error
+}
+
+func (s *CompiledRuleSet) newStream(ctx context.Context, fileID string) *stream {
+	st := &stream{scan: s.newScan(ctx, fileID)}
+	for _, cr := range s.compiled {
+		switch cr.rule.Scope {
+		case ScopeWindow:
+			size := windowSize(cr.rule)
+			st.windows = append(st.windows, windowRule{cr: cr, size: size, seen: make(map[[2]int]bool)})
+			st.maxWindow = max(st.maxWindow, size)
+		case ScopeParagraph:
+			st.paragraphs = append(st.paragraphs, cr)
+		case ScopeDocument:
+			st.documents = append(st.documents, cr)
+		}
+	}
+	st.keepAll = len(st.documents) > 0 || len(s.composites) > 0
+	return st
+}
+
+// full reports whether the stream should stop reading, because the scan was
+// cancelled or has already found more findings than the result cap.
+func (st *stream) full() bool {
+	if st.err != nil {
+		return true
+	}
+	max := st.limits.MaxFindings
+	return max > 0 && len(st.result.Findings) > max
+}
+
+// push adds the next line of the stream, evaluating the batch once it is
+// large enough.
+func (st *stream) push(line string) {
+	st.pageBreaks = append(st.pageBreaks, pageBreaks(line, st.offset)...)
+	st.lines++
+	st.offset += len(line) + 1
+	st.batch = append(st.batch, line)
+	st.batchBytes += len(line) + 1
+	if st.batchBytes >= streamBatchBytes {
+		st.flush()
+	}
+}
+
+// flush evaluates the batch of lines read since the last flush.
+func (st *stream) flush() {
+	if len(st.batch) == 0 || st.err != nil {
+		return
+	}
+	first := st.lines - len(st.batch) + 1
+	doc := newDocumentAt(strings.Join(st.batch, "\n"), first, st.offset-st.batchBytes)
+	st.inline.parse(doc)
+	tasks := st.lineTasks(doc)
+
+	// Windows ending in the batch also cover the lines carried over.
+	var lines []string
+	var starts []int
+	if st.maxWindow > 0 {
+		lines = append(append(lines, st.recent...), st.batch...)
+		starts = append(append(starts, st.starts...), doc.lineStarts...)
+		ctx := newDocumentAt(strings.Join(lines, "\n"), first-len(st.recent), starts[0])
+		carried := len(st.recent)
+		for _, w := range st.windows {
+			tasks = append(tasks, ruleTask{cr: w.cr, doc: ctx, run: func() []Finding {
+				return st.matchWindows(w, ctx, carried)
+			}})
+		}
+	}
+	if st.err = st.run(tasks); st.err != nil {
+		return
+	}
+	if st.maxWindow > 0 {
+		keep := min(st.maxWindow, len(lines))
+		st.recent, st.starts = lines[len(lines)-keep:], starts[len(starts)-keep:]
+	}
+
+	if len(st.paragraphs) > 0 {
+		for i, line := range st.batch {
+			if strings.TrimSpace(line) == "" {
+				st.flushParagraph()
+				continue
+			}
+			if len(st.para) == 0 {
+				st.paraFirst, st.paraStart = first+i, doc.lineStarts[i]
+			}
+			st.para = append(st.para, line)
+		}
+	}
+
+	if st.keepAll {
+		if first > 1 {
+			st.all.WriteByte('\n')
+		}
+		st.all.WriteString(doc.text)
+	}
+	st.batch, st.batchBytes = st.batch[:0], 0
+}
+
+// matchWindows returns the findings of a window rule in the windows of ctx
+// that end after its first carried lines.
+func (st *stream) matchWindows(w windowRule, ctx *document, carried int) []Finding {
+	var segs []segment
+	for i := max(0, carried-w.size+1); i+w.size <= len(ctx.lines); i++ {
+		segs = append(segs, ctx.span(i, i+w.size-1))
+	}
+	if len(segs) == 0 {
+		return nil
+	}
+	findings := st.matchSegments(w.cr, ctx, segs, w.seen)
+	// Later windows start after these, so earlier matches cannot recur.
+	last := segs[len(segs)-1].offset
+	for key := range w.seen {
+		if key[0] < last {
+			delete(w.seen, key)
+		}
+	}
+	return findings
+}
+
+// flushParagraph evaluates the paragraph rules over the current paragraph.
+func (st *stream) flushParagraph() {
+	if len(st.para) == 0 {
+		return
+	}
+	doc := newDocumentAt(strings.Join(st.para, "\n"), st.paraFirst, st.paraStart)
+	seg := []segment{{text: doc.text, offset: doc.base}}
+	for _, cr := range st.paragraphs {
+		for _, f := range st.matchSegments(cr, doc, seg, make(map[[2]int]bool)) {
+			st.add(cr, f)
+		}
+	}
+	st.para = st.para[:0]
+}
+
+// finish evaluates the rules that need the end of the stream and completes
+// the result.
+func (st *stream) finish() *Result {
+	st.flush()
+	if st.err != nil {
+		return nil
+	}
+	if st.full() {
+		st.result.Truncated = true
+	}
+	if len(st.recent) > 0 {
+		// Like Scan, a document shorter than a window is a single window.
+		doc := newDocumentAt(strings.Join(st.recent, "\n"), 1, 0)
+		seg := []segment{{text: doc.text, offset: 0}}
+		for _, w := range st.windows {
+			if st.lines < w.size {
+				for _, f := range st.matchSegments(w.cr, doc, seg, w.seen) {
+					st.add(w.cr, f)
+				}
+			}
+		}
+	}
+	st.flushParagraph()
+	if st.keepAll {
+		doc := newDocument(st.all.String())
+		seg := []segment{{text: doc.text, offset: 0}}
+		for _, cr := range st.documents {
+			for _, f := range st.matchSegments(cr, doc, seg, make(map[[2]int]bool)) {
+				st.add(cr, f)
+			}
+		}
+		st.scanComposites(doc)
+	}
+	return st.scan.finish(st.set.sortFindings)
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestScanReaderMatchesScan(t *testing.T) {
+	config, err := ParseRulesConfig([]byte(`
+rules:
+  - id: secret
+    pattern: SECRET
+    severity: high
+  - id: banner
+    pattern: '(?s)TOP\s+SECRET'
+    scope: window
+    window: 2
+    severity: critical
+  - id: handling
+    pattern: '(?s)SECRET.*NOFORN'
+    scope: paragraph
+    severity: high
+  - id: caveat
+    pattern: '(?s)BEGIN.*END'
+    scope: document
+    severity: medium
+  - id: noforn
+    pattern: NOFORN
+    severity: medium
+  - id: combo
+    type: composite
+    severity: critical
+    composite:
+      all: [secret, noforn]
+      within_lines: 3
+allowlist:
+  values: [SECRET SAUCE]
+  patterns: ['SECRET SAUCE']
+`))
+	if err != nil {
+		t.Fatalf("ParseRulesConfig failed: %v", err)
+	}
+	set, err := CompileRulesConfig(config)
+	if err != nil {
+		t.Fatalf("CompileRulesConfig failed: %v", err)
+	}
+
+	texts := []string{
+		"",
+		"SECRET",
+		"TOP\nSECRET",
+		"BEGIN\nTOP\nSECRET and NOFORN\n\nthe SECRET SAUCE\n# dws:ignore-next-line\nSECRET\nTOP\nSECRET\nNOFORN END\n",
+		strings.Repeat("filler line\nTOP SECRET // dws:ignore banner\n\nSECRET\nNOFORN\n", 50),
+	}
+	for _, text := range texts {
+		want := set.Scan(text, "doc.txt")
+		got, err := set.ScanReader(context.Background(), iotest.OneByteReader(strings.NewReader(text)), "doc.txt")
+		if err != nil {
+			t.Fatalf("ScanReader failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ScanReader(%.40q) differs from Scan:\n got %+v\nwant %+v", text, got, want)
+		}
+	}
+}
+
+func TestScanReaderShortWindow(t *testing.T) {
+	set, err := CompileRules([]Rule{{ID: "pair", Pattern: `(?s)A.*B`, Scope: ScopeWindow, Window: 5}})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	findings, err := set.EvaluateReader(context.Background(), strings.NewReader("A\nx\nB"), "doc.txt")
+	if err != nil {
+		t.Fatalf("EvaluateReader failed: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Line != 1 || findings[0].EndLine != 3 {
+		t.Errorf("Expected one finding spanning lines 1-3, got %+v", findings)
+	}
+}
+
+func TestScanReaderError(t *testing.T) {
+	set, err := CompileRules([]Rule{{ID: "secret", Pattern: "SECRET"}})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	boom := errors.New("boom")
+	if _, err := set.ScanReader(context.Background(), iotest.ErrReader(boom), "doc.txt"); !errors.Is(err, boom) {
+		t.Errorf("Expected read error, got %v", err)
+	}
+}
+
+func TestScanConcurrencyIsDeterministic(t *testing.T) {
+	set, err := CompileRules([]Rule{
+		{ID: "secret", Pattern: "SECRET", Severity: "high"},
+		{ID: "noforn", Pattern: "NOFORN", Severity: "medium"},
+		{ID: "banner", Pattern: `(?s)TOP\s+SECRET`, Scope: ScopeWindow, Window: 3, Severity: "critical"},
+		{ID: "handling", Pattern: `(?s)SECRET.*NOFORN`, Scope: ScopeParagraph, Severity: "high"},
+	})
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	// Large enough to span several line chunks and stream batches.
+	text := strings.Repeat("filler line with nothing\nTOP\nSECRET and NOFORN # dws:ignore noforn\n\nSECRET\n", 6000)
+
+	defer SetLimits(GetLimits())
+	SetLimits(Limits{})
+	defer SetConcurrency(0)
+	SetConcurrency(1)
+	want := set.Scan(text, "doc.txt")
+	if len(want.Findings) == 0 || len(want.Suppressed) == 0 {
+		t.Fatalf("Expected findings and suppressed findings, got %d and %d", len(want.Findings), len(want.Suppressed))
+	}
+	for _, n := range []int{1, 2, 8} {
+		SetConcurrency(n)
+		if got := set.Scan(text, "doc.txt"); !reflect.DeepEqual(got, want) {
+			t.Errorf("Scan with concurrency %d differs from sequential scan", n)
+		}
+		got, err := set.ScanReader(context.Background(), strings.NewReader(text), "doc.txt")
+		if err != nil {
+			t.Fatalf("ScanReader failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ScanReader with concurrency %d differs from sequential scan", n)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Inline suppression directives let a document mark intentional matches in
+// place. "dws:ignore" suppresses findings starting on the same line and
+// "dws:ignore-next-line" those starting on the following line. Either may be
+// followed by a comma-separated list of rule IDs; without one, every rule is
+// suppressed.
+const (
+	directiveIgnore         = "ignore"
+	directiveIgnoreNextLine = "ignore-next-line"
+)
+
+var directivePattern = regexp.MustCompile(`dws:(ignore(?:-next-line)?)(?:[ \t]+(\w[\w.-]*(?:[ \t]*,[ \t]*\w[\w.-]*)*))?`)
+
+// Suppression reports an inline directive found in a scanned document and
+// how many findings it suppressed. Stale directives suppressed nothing.
+type Suppression struct {
+	Line       int      `json:"line"`
+	Directive  string   `json:"directive"`
+	TargetLine int      `json:"target_line"`
+	RuleIDs    []string `json:"rule_ids,omitempty"`
+	Suppressed int      `json:"suppressed"`
+	Stale      bool     `json:"stale"`
+}
+
+// inlineSuppressions tracks the directives of one document during a scan.
+type inlineSuppressions struct {
+	list   []Suppression
+	byLine map[int][]int
+}
+
+// parse adds the directives in doc, which may be part of a longer stream.
+func (s *inlineSuppressions) parse(doc *document) {
+	if s == nil {
+		return
+	}
+	for i, line := range doc.lines {
+		if !strings.Contains(line, "dws:ignore") {
+			continue
+		}
+		for _, m := range directivePattern.FindAllStringSubmatchIndex(line, -1) {
+			if m[1] < len(line) && (isWordRune(rune(line[m[1]])) || line[m[1]] == '-') {
+				continue
+			}
+			n := doc.first + i
+			sup := Suppression{Line: n, Directive: line[m[2]:m[3]], TargetLine: n}
+			if sup.Directive == directiveIgnoreNextLine {
+				sup.TargetLine = n + 1
+			}
+			if m[4] >= 0 {
+				for _, id := range strings.Split(line[m[4]:m[5]], ",") {
+					sup.RuleIDs = append(sup.RuleIDs, strings.TrimSpace(id))
+				}
+			}
+			s.byLine[sup.TargetLine] = append(s.byLine[sup.TargetLine], len(s.list))
+			s.list = append(s.list, sup)
+		}
+	}
+}
+
+// lookup returns the index of the first directive targeting the finding's
+// starting line and rule, or -1. It only reads the directives, so tasks may
+// call it concurrently.
+func (s *inlineSuppressions) lookup(f Finding) int {
+	if s == nil {
+		return -1
+	}
+	for _, idx := range s.byLine[f.Line] {
+		sup := &s.list[idx]
+		if len(sup.RuleIDs) == 0 || slices.Contains(sup.RuleIDs, f.RuleID) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// record counts the finding against the directive that suppressed it.
+func (s *inlineSuppressions) record(f Finding) {
+	if idx := s.lookup(f); idx >= 0 {
+		s.list[idx].Suppressed++
+	}
+}
+
+// report returns the directives in document order with stale ones marked.
+func (s *inlineSuppressions) report() []Suppression {
+	if s == nil {
+		return nil
+	}
+	for i := range s.list {
+		s.list[i].Stale = s.list[i].Suppressed == 0
+	}
+	return s.list
+}
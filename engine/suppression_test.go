@@ -0,0 +1,81 @@
+package engine
+
+import "testing"
+
+func suppressionTestRules() []Rule {
+	return []Rule{
+		{ID: "password", Pattern: `password=\S+`, Severity: "high"},
+		{ID: "token", Pattern: `tok_[a-z0-9]+`, Severity: "high"},
+	}
+}
+
+func TestInlineSuppressionDirectives(t *testing.T) {
+	set, err := CompileRules(suppressionTestRules())
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	text := "password=hunter2 tok_abc # dws:ignore password\n" +
+		"// dws:ignore-next-line\n" +
+		"password=swordfish tok_def\n" +
+		"tok_ghi <!-- dws:ignore token, password -->\n" +
+		"password=letmein # dws:ignore token"
+	result := set.Scan(text, "app.conf")
+
+	if len(result.Findings) != 2 || result.Findings[0].Match != "tok_abc" || result.Findings[1].Match != "password=letmein" {
+		t.Errorf("Unexpected findings: %+v", result.Findings)
+	}
+	if len(result.Suppressed) != 4 {
+		t.Fatalf("Expected 4 suppressed findings, got %+v", result.Suppressed)
+	}
+	if result.Suppressed[1].SuppressedBy != "inline:ignore-next-line" {
+		t.Errorf("Unexpected suppressed_by: %q", result.Suppressed[1].SuppressedBy)
+	}
+
+	want := []Suppression{
+		{Line: 1, Directive: "ignore", TargetLine: 1, Suppressed: 1},
+		{Line: 2, Directive: "ignore-next-line", TargetLine: 3, Suppressed: 2},
+		{Line: 4, Directive: "ignore", TargetLine: 4, Suppressed: 1},
+		{Line: 5, Directive: "ignore", TargetLine: 5, Stale: true},
+	}
+	if len(result.Suppressions) != len(want) {
+		t.Fatalf("Expected %d suppressions, got %+v", len(want), result.Suppressions)
+	}
+	for i, w := range want {
+		got := result.Suppressions[i]
+		if got.Line != w.Line || got.Directive != w.Directive || got.TargetLine != w.TargetLine || got.Suppressed != w.Suppressed || got.Stale != w.Stale {
+			t.Errorf("Suppression %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+	if ids := result.Suppressions[2].RuleIDs; len(ids) != 2 || ids[0] != "token" || ids[1] != "password" {
+		t.Errorf("Unexpected rule IDs: %v", ids)
+	}
+}
+
+func TestInlineSuppressionRequiresDirectiveBoundary(t *testing.T) {
+	set, err := CompileRules(suppressionTestRules())
+	if err != nil {
+		t.Fatalf("CompileRules failed: %v", err)
+	}
+	result := set.Scan("password=x dws:ignored", "app.conf")
+	if len(result.Findings) != 1 || len(result.Suppressions) != 0 {
+		t.Errorf("Expected dws:ignored not to be a directive, got %+v", result)
+	}
+}
+
+func TestDisableInlineSuppressions(t *testing.T) {
+	set, err := CompileRulesConfig(&RulesConfig{Rules: suppressionTestRules(), DisableInlineSuppressions: true})
+	if err != nil {
+		t.Fatalf("CompileRulesConfig failed: %v", err)
+	}
+	result := set.Scan("password=hunter2 # dws:ignore", "app.conf")
+	if len(result.Findings) != 1 || len(result.Suppressed) != 0 || len(result.Suppressions) != 0 {
+		t.Errorf("Expected directives to be ignored, got %+v", result)
+	}
+}
+
+func TestEvaluateHonorsInlineSuppressions(t *testing.T) {
+	findings := Evaluate("password=hunter2 # dws:ignore\npassword=swordfish", "app.conf", suppressionTestRules())
+	if len(findings) != 1 || findings[0].Line != 2 {
+		t.Errorf("Expected only the unsuppressed line, got %+v", findings)
+	}
+}
@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Validator checks a candidate match and reports whether it is genuine.
+type Validator func(match string) bool
+
+// validators holds the built-in validators referenced by Rule.Validator.
+var validators = map[string]Validator{
+	"luhn":    validateLuhn,
+	"ssn":     validateSSN,
+	"iban":    validateIBAN,
+	"aba":     validateABA,
+	"aws_key": validateAWSKey,
+}
+
+// lookupValidator returns the named validator, or an error if it is unknown.
+func lookupValidator(name string) (Validator, error) {
+	v, ok := validators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown validator %q", name)
+	}
+	return v, nil
+}
+
+// digitsOf returns the ASCII digits in s, ignoring separators and labels.
+func digitsOf(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// validateLuhn checks a payment card number with the Luhn checksum.
+func validateLuhn(match string) bool {
+	digits := digitsOf(match)
+	if len(digits) < 12 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// validateSSN applies the SSA rules for valid area, group and serial numbers.
+func validateSSN(match string) bool {
+	digits := digitsOf(match)
+	if len(digits) != 9 {
+		return false
+	}
+	area, group, serial := digits[:3], digits[3:5], digits[5:]
+	if area == "000" || area == "666" || area[0] == '9' {
+		return false
+	}
+	return group != "00" && serial != "0000"
+}
+
+// validateIBAN checks the structure and mod-97 check digits of an IBAN.
+func validateIBAN(match string) bool {
+	var iban strings.Builder
+	for _, r := range strings.ToUpper(match) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			iban.WriteRune(r)
+		}
+	}
+	s := iban.String()
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	if s[0] < 'A' || s[0] > 'Z' || s[1] < 'A' || s[1] > 'Z' || s[2] < '0' || s[2] > '9' || s[3] < '0' || s[3] > '9' {
+		return false
+	}
+	rearranged := s[4:] + s[:4]
+	remainder := 0
+	for _, r := range rearranged {
+		if r >= 'A' {
+			n := int(r-'A') + 10
+			remainder = (remainder*100 + n) % 97
+		} else {
+			remainder = (remainder*10 + int(r-'0')) % 97
+		}
+	}
+	return remainder == 1
+}
+
+// validateABA checks an ABA routing transit number checksum.
+func validateABA(match string) bool {
+	d := digitsOf(match)
+	if len(d) != 9 {
+		return false
+	}
+	n := func(i int) int { return int(d[i] - '0') }
+	sum := 3*(n(0)+n(3)+n(6)) + 7*(n(1)+n(4)+n(7)) + n(2) + n(5) + n(8)
+	return sum%10 == 0
+}
+
+var (
+	awsKeyIDPattern  = regexp.MustCompile(`(?:A3T[A-Z0-9]|AKIA|ASIA|ABIA|ACCA|AGPA|AIDA|AIPA|ANPA|ANVA|APKA|AROA|ASCA)[A-Z2-7]{16}`)
+	awsSecretPattern = regexp.MustCompile(`[A-Za-z0-9/+]{40}`)
+)
+
+// minAWSSecretEntropy is the minimum Shannon entropy, in bits per character,
+// for a 40-character string to be treated as an AWS secret access key.
+const minAWSSecretEntropy = 4.0
+
+// validateAWSKey accepts AWS access key IDs with a known prefix and base32
+// body, and 40-character secret keys with high entropy.
+func validateAWSKey(match string) bool {
+	if id := awsKeyIDPattern.FindString(match); id != "" {
+		return true
+	}
+	secret := awsSecretPattern.FindString(match)
+	return secret != "" && shannonEntropy(secret) >= minAWSSecretEntropy
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	entropy := 0.0
+	n := float64(len(s))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
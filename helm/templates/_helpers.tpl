@@ -177,12 +177,13 @@ seccompProfile:
 {{- end }}
 
 {{/*
-Environment variables
+Environment variables. Values are rendered with tpl, so they can reference
+other chart values.
 */}}
 {{- define "dws.env" -}}
 {{- range .Values.env }}
 - name: {{ .name }}
-  value: {{ .value | quote }}
+  value: {{ tpl (toString .value) $ | quote }}
 {{- end }}
 {{- end }}
 
@@ -35,6 +35,9 @@ type SmartAnalysisConfig struct {
 
 	// Skip LLM if confidence in regex results is high
 	SkipIfHighConfidence bool `yaml:"skip_if_high_confidence"`
+
+	// Drop regex findings below this severity before deciding on LLM use
+	MinSeverity engine.Severity `yaml:"-"`
 }
 
 // SmartAnalysisResult combines regex pre-filtering with selective LLM usage
@@ -70,8 +73,10 @@ func NewSmartAnalyzer(analyzer *Analyzer, config SmartAnalysisConfig) *SmartAnal
 	}
 }
 
-// AnalyzeWithPrefiltering performs intelligent analysis using rules as filters
-func (s *SmartAnalyzer) AnalyzeWithPrefiltering(ctx context.Context, text, filename string, rules []engine.Rule) (*SmartAnalysisResult, error) {
+// AnalyzeWithPrefiltering performs intelligent analysis using rules as filters.
+// The document is scanned with the compiled ruleset, so its allowlists,
+// inline suppressions and policy apply as they do for /scan.
+func (s *SmartAnalyzer) AnalyzeWithPrefiltering(ctx context.Context, text, filename string, rules *engine.CompiledRuleSet) (*SmartAnalysisResult, error) {
 	result := &SmartAnalysisResult{
 		RegexFindings: []engine.Finding{},
 		LLMUsed:       false,
@@ -79,7 +84,11 @@ func (s *SmartAnalyzer) AnalyzeWithPrefiltering(ctx context.Context, text, filen
 	}
 
 	// Step 1: Always run regex analysis first (fast and cheap)
-	regexFindings := engine.Evaluate(text, filename, rules)
+	scan, err := rules.ScanContext(ctx, text, filename)
+	if err != nil {
+		return nil, err
+	}
+	regexFindings := engine.FilterSeverity(scan.Findings, s.config.MinSeverity)
 	result.RegexFindings = regexFindings
 
 	logrus.WithFields(logrus.Fields{
@@ -148,6 +157,17 @@ func (s *SmartAnalyzer) AnalyzeWithPrefiltering(ctx context.Context, text, filen
 	return result, nil
 }
 
+// sameSeverity compares severities by canonical level, so aliases such as
+// "info" and "informational" match. Unknown severities compare by name.
+func sameSeverity(a, b string) bool {
+	sa, okA := engine.ParseSeverity(a)
+	sb, okB := engine.ParseSeverity(b)
+	if okA && okB {
+		return sa == sb
+	}
+	return strings.EqualFold(a, b)
+}
+
 // shouldUseLLM determines if LLM analysis is warranted based on regex results
 func (s *SmartAnalyzer) shouldUseLLM(text string, findings []engine.Finding) (bool, string) {
 	// Check document length
@@ -164,7 +184,7 @@ func (s *SmartAnalyzer) shouldUseLLM(text string, findings []engine.Finding) (bo
 	hasTargetSeverity := false
 	for _, finding := range findings {
 		for _, targetSeverity := range s.config.TriggerSeverities {
-			if strings.EqualFold(finding.Severity, targetSeverity) {
+			if sameSeverity(finding.Severity, targetSeverity) {
 				hasTargetSeverity = true
 				break
 			}
@@ -183,7 +203,7 @@ func (s *SmartAnalyzer) shouldUseLLM(text string, findings []engine.Finding) (bo
 		hasTargetRuleType := false
 		for _, finding := range findings {
 			for _, targetType := range s.config.AnalyzeRuleTypes {
-				if strings.Contains(finding.RuleID, targetType) {
+				if strings.EqualFold(findingCategory(finding), targetType) {
 					hasTargetRuleType = true
 					break
 				}
@@ -233,10 +253,8 @@ func (s *SmartAnalyzer) createFocusedAnalysisRequest(text, filename string, find
 
 	ruleCategories := make(map[string]bool)
 	for _, finding := range findings {
-		// Extract category from rule ID (e.g., "disease-rabies" -> "disease")
-		parts := strings.Split(finding.RuleID, "-")
-		if len(parts) > 0 {
-			category := parts[0]
+		category := strings.ToLower(findingCategory(finding))
+		if category != "" {
 			if !ruleCategories[category] {
 				ruleCategories[category] = true
 
@@ -269,6 +287,16 @@ func (s *SmartAnalyzer) createFocusedAnalysisRequest(text, filename string, find
 	}
 }
 
+// findingCategory returns the category of the rule that produced a finding.
+// Rules without an explicit category fall back to their ID prefix
+// (e.g., "disease-rabies" -> "disease").
+func findingCategory(finding engine.Finding) string {
+	if finding.Category != "" {
+		return finding.Category
+	}
+	return strings.SplitN(finding.RuleID, "-", 2)[0]
+}
+
 // GetOptimizationStats returns statistics about LLM usage optimization
 func (s *SmartAnalyzer) GetOptimizationStats() map[string]interface{} {
 	return map[string]interface{}{
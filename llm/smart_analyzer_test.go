@@ -0,0 +1,49 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"dws/engine"
+)
+
+func TestShouldUseLLMMatchesCategory(t *testing.T) {
+	s := NewSmartAnalyzer(nil, SmartAnalysisConfig{
+		TriggerSeverities: []string{"high"},
+		AnalyzeRuleTypes:  []string{"classification"},
+	})
+	text := strings.Repeat("x", 200)
+
+	findings := []engine.Finding{
+		{RuleID: "SECRET_DETECTION", Severity: "HIGH", Category: "CLASSIFICATION"},
+	}
+	if ok, reason := s.shouldUseLLM(text, findings); !ok {
+		t.Errorf("Expected LLM to be used for matching category, got: %s", reason)
+	}
+
+	findings = []engine.Finding{
+		{RuleID: "classification-like-id", Severity: "HIGH", Category: "PORTION_MARKING"},
+	}
+	if ok, _ := s.shouldUseLLM(text, findings); ok {
+		t.Errorf("Expected rule ID substring not to match category")
+	}
+}
+
+func TestShouldUseLLMFallsBackToIDPrefix(t *testing.T) {
+	s := NewSmartAnalyzer(nil, SmartAnalysisConfig{
+		TriggerSeverities: []string{"high"},
+		AnalyzeRuleTypes:  []string{"disease"},
+	})
+	findings := []engine.Finding{{RuleID: "disease-rabies", Severity: "high"}}
+	if ok, reason := s.shouldUseLLM(strings.Repeat("x", 200), findings); !ok {
+		t.Errorf("Expected uncategorized rule to match by ID prefix, got: %s", reason)
+	}
+}
+
+func TestShouldUseLLMMatchesSeverityAliases(t *testing.T) {
+	s := NewSmartAnalyzer(nil, SmartAnalysisConfig{TriggerSeverities: []string{"info"}})
+	findings := []engine.Finding{{RuleID: "raccoon-mention", Severity: "informational"}}
+	if ok, reason := s.shouldUseLLM(strings.Repeat("x", 200), findings); !ok {
+		t.Errorf("Expected info to match informational, got: %s", reason)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -41,6 +42,89 @@ func initLogging() {
 	logrus.Printf("DEBUG_MODE: %t", debugMode)
 }
 
+// initContextChars configures the finding context window from CONTEXT_CHARS.
+func initContextChars() {
+	value := os.Getenv("CONTEXT_CHARS")
+	if value == "" {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		logrus.WithError(err).Warnf("Invalid CONTEXT_CHARS %q, using default of %d", value, engine.DefaultContextChars)
+		return
+	}
+	engine.SetContextChars(n)
+}
+
+// initConcurrency configures how many goroutines evaluate a document from
+// SCAN_CONCURRENCY.
+func initConcurrency() {
+	value := os.Getenv("SCAN_CONCURRENCY")
+	if value == "" {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		logrus.WithError(err).Warnf("Invalid SCAN_CONCURRENCY %q, using one worker per CPU", value)
+		return
+	}
+	engine.SetConcurrency(n)
+}
+
+// initMaxBodySize configures the largest document the scan endpoints accept
+// from MAX_BODY_SIZE, in bytes.
+func initMaxBodySize() {
+	value := os.Getenv("MAX_BODY_SIZE")
+	if value == "" {
+		return
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		logrus.Warnf("Invalid MAX_BODY_SIZE %q, using default of %d", value, api.DefaultMaxBodySize)
+		return
+	}
+	api.SetMaxBodySize(n)
+}
+
+// initScanTimeout configures the time budget for each scan from SCAN_TIMEOUT.
+func initScanTimeout() {
+	value := os.Getenv("SCAN_TIMEOUT")
+	if value == "" {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		logrus.Warnf("Invalid SCAN_TIMEOUT %q, using default of %s", value, api.DefaultScanTimeout)
+		return
+	}
+	api.SetScanTimeout(d)
+}
+
+// initLimits configures the findings and per-rule match caps from
+// MAX_FINDINGS and MAX_MATCHES_PER_RULE.
+func initLimits() {
+	limits := engine.GetLimits()
+	for _, v := range []struct {
+		name  string
+		value *int
+	}{
+		{"MAX_FINDINGS", &limits.MaxFindings},
+		{"MAX_MATCHES_PER_RULE", &limits.MaxMatchesPerRule},
+	} {
+		value := os.Getenv(v.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			logrus.Warnf("Invalid %s %q, using default of %d", v.name, value, *v.value)
+			continue
+		}
+		*v.value = n
+	}
+	engine.SetLimits(limits)
+}
+
 func NewServer(rulesFile string) (*http.Server, error) {
 	if rulesFile != "" {
 		if err := engine.LoadRulesFromYAML(rulesFile); err != nil {
@@ -88,8 +172,11 @@ func NewServer(rulesFile string) (*http.Server, error) {
 	mux.HandleFunc("/scan/llm", api.LLMScanHandler)
 	mux.HandleFunc("/scan/hybrid", api.HybridScanHandler)
 	mux.HandleFunc("/scan/smart", api.SmartScanHandler)
+	mux.HandleFunc("/scan/redact", api.RedactHandler)
 	mux.HandleFunc("/rules/reload", api.ReloadRulesHandler)
 	mux.HandleFunc("/rules/load", api.LoadRulesFromFileHandler)
+	mux.HandleFunc("/rules/validate", api.ValidateRulesHandler)
+	mux.HandleFunc("/rules/test", api.RuleExamplesHandler)
 	mux.HandleFunc("/ruleset", api.RulesetHandler)
 	mux.HandleFunc("/health", api.HealthHandler)
 	mux.HandleFunc("/docs", api.DocsHandler)
@@ -169,8 +256,16 @@ func run() error {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "rules" {
+		os.Exit(runRulesCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
 	initLogging()
 	engine.SetDebugMode(debugMode)
+	initContextChars()
+	initMaxBodySize()
+	initConcurrency()
+	initScanTimeout()
+	initLimits()
 
 	if err := run(); err != nil {
 		logrus.Fatal(err)
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/sirupsen/logrus"
+
+	"dws/engine"
+)
+
+const rulesUsage = `usage: dws rules <command> [flags] <file>...
+
+commands:
+  lint    check rules files for invalid patterns, duplicate IDs, unknown
+          fields and other problems
+  test    check that each rule matches its examples.match texts and does
+          not match its examples.no_match texts
+`
+
+// runRulesCommand runs a "dws rules" subcommand and returns its exit code.
+func runRulesCommand(args []string, stdout, stderr io.Writer) int {
+	// Problems are reported as diagnostics, so engine logging is noise here.
+	logrus.SetOutput(io.Discard)
+	if len(args) == 0 {
+		fmt.Fprint(stderr, rulesUsage)
+		return 2
+	}
+	switch args[0] {
+	case "lint":
+		return runRulesLint(args[1:], stdout, stderr)
+	case "test":
+		return runRulesTest(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown rules command %q\n%s", args[0], rulesUsage)
+		return 2
+	}
+}
+
+// lintReport is the JSON output of dws rules lint for one file.
+type lintReport struct {
+	File        string              `json:"file"`
+	Valid       bool                `json:"valid"`
+	Diagnostics []engine.Diagnostic `json:"diagnostics"`
+}
+
+// runRulesLint lints each rules file and exits non-zero if any has errors.
+func runRulesLint(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("dws rules lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "print diagnostics as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprint(stderr, "usage: dws rules lint [-json] <file>...\n")
+		return 2
+	}
+
+	code := 0
+	var reports []lintReport
+	for _, path := range fs.Args() {
+		diags, err := engine.LintRulesFile(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			code = 1
+			continue
+		}
+		valid := !engine.HasErrors(diags)
+		if !valid {
+			code = 1
+		}
+		if *asJSON {
+			reports = append(reports, lintReport{File: path, Valid: valid, Diagnostics: diags})
+			continue
+		}
+		errCount := 0
+		for _, d := range diags {
+			fmt.Fprintf(stdout, "%s:%s\n", path, d)
+			if d.Level == engine.DiagnosticError {
+				errCount++
+			}
+		}
+		fmt.Fprintf(stdout, "%s: %d error(s), %d warning(s)\n", path, errCount, len(diags)-errCount)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(reports)
+	}
+	return code
+}
+
+// testReport is the JSON output of dws rules test for one file.
+type testReport struct {
+	File string `json:"file"`
+	*engine.ExampleReport
+}
+
+// runRulesTest checks the examples of every rule in each rules file and
+// exits non-zero if any example fails or a file cannot be compiled.
+func runRulesTest(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("dws rules test", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprint(stderr, "usage: dws rules test [-json] <file>...\n")
+		return 2
+	}
+
+	code := 0
+	var reports []testReport
+	for _, path := range fs.Args() {
+		set, err := engine.LoadRuleSet(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			code = 1
+			continue
+		}
+		report := set.TestExamples()
+		if report.Failed > 0 {
+			code = 1
+		}
+		if *asJSON {
+			reports = append(reports, testReport{File: path, ExampleReport: report})
+			continue
+		}
+		for _, r := range report.Results {
+			if !r.Passed {
+				fmt.Fprintf(stdout, "%s: FAIL rule %s: %s: %q\n", path, r.RuleID, r.Message, r.Example)
+			}
+		}
+		fmt.Fprintf(stdout, "%s: %d passed, %d failed, %d rule(s) without examples\n", path, report.Passed, report.Failed, len(report.Untested))
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(reports)
+	}
+	return code
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRulesLintCommand(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yaml")
+	bad := filepath.Join(dir, "bad.yaml")
+	os.WriteFile(good, []byte("rules:\n  - id: r1\n    pattern: foo\n    severity: high\n"), 0644)
+	os.WriteFile(bad, []byte("rules:\n  - id: r1\n    pattern: foo\n    severity: high\n  - id: r1\n    pattern: \"(\"\n    severity: high\n"), 0644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runRulesCommand([]string{"lint", good}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0 for valid rules, got %d: %s%s", code, stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	if code := runRulesCommand([]string{"lint", good, bad}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit 1 for invalid rules, got %d", code)
+	}
+	out := stdout.String()
+	for _, want := range []string{bad + ":5:5: error: rule r1: duplicate rule id", bad + ":6:5: error: rule r1: invalid pattern", bad + ": 2 error(s), 0 warning(s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	stdout.Reset()
+	if code := runRulesCommand([]string{"lint", "-json", bad}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit 1 for invalid rules, got %d", code)
+	}
+	var reports []lintReport
+	if err := json.Unmarshal(stdout.Bytes(), &reports); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(reports) != 1 || reports[0].Valid || len(reports[0].Diagnostics) != 2 {
+		t.Errorf("unexpected JSON report: %+v", reports)
+	}
+}
+
+func TestRulesTestCommand(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.yaml")
+	os.WriteFile(path, []byte(`rules:
+  - id: r1
+    pattern: secret
+    severity: high
+    examples:
+      match: ["top secret"]
+      no_match: ["secretary", "public"]
+  - id: r2
+    pattern: foo
+    severity: low
+`), 0644)
+
+	var stdout, stderr bytes.Buffer
+	if code := runRulesCommand([]string{"test", path}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit 1 for failing examples, got %d", code)
+	}
+	out := stdout.String()
+	for _, want := range []string{`FAIL rule r1: expected no match, matched "secret" on line 1: "secretary"`, "2 passed, 1 failed, 1 rule(s) without examples"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	stdout.Reset()
+	if code := runRulesCommand([]string{"test", "-json", "config/rules.yaml"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected config examples to pass, got %d: %s", code, stdout.String())
+	}
+	var reports []testReport
+	if err := json.Unmarshal(stdout.Bytes(), &reports); err != nil || len(reports) != 1 || reports[0].Passed == 0 {
+		t.Errorf("unexpected JSON report: %v %s", err, stdout.String())
+	}
+}
+
+func TestRulesCommandUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	for _, args := range [][]string{{}, {"frobnicate"}, {"lint"}, {"test"}} {
+		if code := runRulesCommand(args, &stdout, &stderr); code != 2 {
+			t.Errorf("expected exit 2 for %v, got %d", args, code)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+)
+
+// maxArchivePartSize caps the decompressed size of a single file inside a
+// ZIP-based document, and maxArchiveSize the total read from one document,
+// so that a small upload cannot expand without bound.
+const (
+	maxArchivePartSize = 64 << 20
+	maxArchiveSize     = 256 << 20
+)
+
+// oleSignature starts OLE compound files, which is how Office stores
+// password-protected documents even when they carry an OOXML extension.
+var oleSignature = []byte{0xd0, 0xcf, 0x11, 0xe0, 0xa1, 0xb1, 0x1a, 0xe1}
+
+// archive reads the files of a ZIP-based document, keeping count of how
+// much has been decompressed. Reads stop once ctx is done.
+type archive struct {
+	ctx   context.Context
+	files map[string]*zip.File
+	read  int64
+}
+
+// openArchive opens a ZIP-based document. invalid is wrapped by the errors
+// for data that is not a ZIP archive.
+func openArchive(ctx context.Context, data []byte, invalid error) (*archive, error) {
+	if bytes.HasPrefix(data, oleSignature) {
+		return nil, fmt.Errorf("%w: password-protected Office documents are not supported", ErrEncryptedDocument)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", invalid, err)
+	}
+	a := &archive{ctx: ctx, files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		a.files[f.Name] = f
+	}
+	return a, nil
+}
+
+// glob returns the names of the files matching pattern, in natural order
+// so that "slide10.xml" sorts after "slide9.xml".
+func (a *archive) glob(pattern string) []string {
+	var names []string
+	for name := range a.files {
+		if ok, _ := path.Match(pattern, name); ok {
+			names = append(names, name)
+		}
+	}
+	sortParts(names)
+	return names
+}
+
+// sortParts sorts part names in natural order, assuming names that differ
+// only in a trailing number.
+func sortParts(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) < len(names[j])
+		}
+		return names[i] < names[j]
+	})
+}
+
+// readFile returns the contents of name, or nil if the archive has no such
+// file. It returns the context's error once the context is done.
+func (a *archive) readFile(name string) ([]byte, error) {
+	if err := a.ctx.Err(); err != nil {
+		return nil, err
+	}
+	f, ok := a.files[name]
+	if !ok {
+		return nil, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	defer rc.Close()
+	limit := min(maxArchivePartSize, maxArchiveSize-a.read)
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is too large to extract", name)
+	}
+	a.read += int64(len(data))
+	return data, nil
+}
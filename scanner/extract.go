@@ -1,22 +1,65 @@
 package scanner
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
 	"strings"
 )
 
+// ErrEncryptedDocument is returned for encrypted documents that cannot be
+// decrypted, either because the password is missing or wrong or because the
+// encryption is not supported. Such documents cannot be scanned.
+var ErrEncryptedDocument = errors.New("encrypted document")
+
+// ExtractOptions holds optional settings for text extraction.
+type ExtractOptions struct {
+	// Password decrypts encrypted documents that the empty password does
+	// not open. It may be the user or the owner password.
+	Password string
+
+	// HTMLAttributes includes the values of HTML attributes shown to
+	// readers, such as alt and title, and the content of meta elements.
+	HTMLAttributes bool
+
+	// HTMLComments includes the text of HTML comments.
+	HTMLComments bool
+}
+
 // ExtractText extracts text from various file formats
 func ExtractText(data []byte, filename string) (string, error) {
+	return ExtractTextWith(data, filename, ExtractOptions{})
+}
+
+// ExtractTextWith extracts text like ExtractText using opts.
+func ExtractTextWith(data []byte, filename string, opts ExtractOptions) (string, error) {
+	return ExtractTextContext(context.Background(), data, filename, opts)
+}
+
+// ExtractTextContext is ExtractTextWith with a context. It returns the
+// context's error if the context is done before or during extraction.
+func ExtractTextContext(ctx context.Context, data []byte, filename string, opts ExtractOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	ext := strings.ToLower(filepath.Ext(filename))
 
 	switch ext {
 	case ".pdf":
-		return extractPDFText(data)
+		return extractPDFText(ctx, data, opts.Password)
 	case ".txt":
 		return string(data), nil
 	case ".html", ".htm":
-		return extractHTMLText(data)
+		return extractHTMLText(data, opts)
+	case ".docx", ".docm", ".xlsx", ".xlsm", ".pptx", ".pptm":
+		return extractOOXMLText(ctx, data, ext)
+	case ".odt", ".ods", ".odp":
+		return extractODFText(ctx, data)
+	case ".rtf":
+		return extractRTFText(data)
 	case "":
 		// No extension - try to extract as text
 		return string(data), nil
@@ -30,6 +73,54 @@ func ExtractText(data []byte, filename string) (string, error) {
 	}
 }
 
+// ExtractReader returns the text of the document read from r. Plain text is
+// streamed through without reading the whole document, after checking the
+// start of files with unknown extensions for binary data. Formats that need
+// the whole document, such as PDF, HTML and Office documents, are read fully
+// and extracted with ExtractText.
+func ExtractReader(r io.Reader, filename string) (io.Reader, error) {
+	return ExtractReaderWith(r, filename, ExtractOptions{})
+}
+
+// ExtractReaderWith returns the text of the document read from r like
+// ExtractReader using opts.
+func ExtractReaderWith(r io.Reader, filename string, opts ExtractOptions) (io.Reader, error) {
+	return ExtractReaderContext(context.Background(), r, filename, opts)
+}
+
+// ExtractReaderContext is ExtractReaderWith with a context, which bounds the
+// extraction of formats that are read fully. Streamed text is bounded by
+// the scan that reads it.
+func ExtractReaderContext(ctx context.Context, r io.Reader, filename string, opts ExtractOptions) (io.Reader, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	switch ext {
+	case ".txt", "":
+		return r, nil
+	case ".pdf", ".html", ".htm", ".docx", ".docm", ".xlsx", ".xlsm", ".pptx", ".pptm",
+		".odt", ".ods", ".odp", ".rtf":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		text, err := ExtractTextContext(ctx, data, filename, opts)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(text), nil
+	default:
+		br := bufio.NewReader(r)
+		head, err := br.Peek(513)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if isBinaryData(head) {
+			return nil, fmt.Errorf("unsupported file format: %s", ext)
+		}
+		return br, nil
+	}
+}
+
 // isBinaryData performs a basic check to see if data is likely binary
 func isBinaryData(data []byte) bool {
 	// Check first 512 bytes for null bytes which are common in binary files
@@ -61,38 +152,3 @@ func isBinaryData(data []byte) bool {
 	}
 	return false
 }
-
-// extractPDFText extracts text from PDF files
-func extractPDFText(data []byte) (string, error) {
-	// TODO: Implement PDF text extraction
-	// For now, return a placeholder
-	return "PDF text extraction not implemented", nil
-}
-
-// extractHTMLText extracts text from HTML files
-func extractHTMLText(data []byte) (string, error) {
-	html := string(data)
-
-	// Simple HTML-to-text conversion (very basic)
-	// Remove HTML tags
-	text := strings.ReplaceAll(html, "<script>", "")
-	text = strings.ReplaceAll(text, "</script>", "")
-	text = strings.ReplaceAll(text, "<style>", "")
-	text = strings.ReplaceAll(text, "</style>", "")
-
-	// Basic tag removal
-	start := strings.Index(text, "<")
-	for start != -1 {
-		end := strings.Index(text[start:], ">")
-		if end == -1 {
-			break
-		}
-		text = text[:start] + text[start+end+1:]
-		start = strings.Index(text, "<")
-	}
-
-	// TODO: Decode HTML entities later if needed
-	// Currently using basic extraction
-
-	return strings.TrimSpace(text), nil
-}
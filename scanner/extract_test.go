@@ -1,14 +1,65 @@
 package scanner
 
 import (
+	"context"
+	"errors"
+	"io"
+	"os"
 	"strings"
 	"testing"
+
+	"dws/engine"
 )
 
 func TestExtractTextPDF(t *testing.T) {
-	// Skip this test if we can't create a valid PDF for testing
-	// The actual PDF parsing functionality is tested with real PDFs in integration tests
-	t.Skip("PDF extraction requires a valid PDF file - tested in integration")
+	data, err := os.ReadFile("../testfiles/sample.pdf")
+	if err != nil {
+		t.Fatalf("read sample: %v", err)
+	}
+	text, err := ExtractText(data, "sample.pdf")
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	pages := strings.Split(text, engine.PageBreak)
+	if len(pages) != 5 {
+		t.Fatalf("Expected 5 pages, got %d", len(pages))
+	}
+	if !strings.HasPrefix(pages[0], "The Grand Raccoon Manifesto\n") {
+		t.Errorf("Unexpected first page %q", pages[0])
+	}
+	if !strings.Contains(pages[4], "The raccoon Power Index is expected to reach 100% by 2050") {
+		t.Errorf("Unexpected last page %q", pages[4])
+	}
+}
+
+func TestExtractTextContextCancelled(t *testing.T) {
+	pdf, err := os.ReadFile("../testfiles/sample.pdf")
+	if err != nil {
+		t.Fatalf("read sample: %v", err)
+	}
+	docx := buildZip(t, "word/document.xml", `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>SECRET</w:t></w:r></w:p></w:body></w:document>`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, data := range map[string][]byte{"doc.pdf": pdf, "doc.docx": docx, "doc.odt": docx} {
+		if _, err := ExtractTextContext(ctx, data, name, ExtractOptions{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled, got %v", name, err)
+		}
+		if _, err := ExtractReaderContext(ctx, strings.NewReader(string(data)), name, ExtractOptions{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected context.Canceled from ExtractReaderContext, got %v", name, err)
+		}
+	}
+
+	// Archives stop between parts once the context is done.
+	ctx, cancel = context.WithCancel(context.Background())
+	a, err := openArchive(ctx, docx, errInvalidOOXML)
+	if err != nil {
+		t.Fatalf("openArchive failed: %v", err)
+	}
+	cancel()
+	if _, err := a.readFile("word/document.xml"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled reading a part, got %v", err)
+	}
 }
 
 func TestExtractTextHTML(t *testing.T) {
@@ -148,3 +199,36 @@ func TestBinaryDataDetection(t *testing.T) {
 		}
 	}
 }
+
+func TestExtractReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     string
+		want     string
+		wantErr  bool
+	}{
+		{"text streams unchanged", "notes.txt", "line one\nline two\n", "line one\nline two\n", false},
+		{"unknown text format", "config.yaml", "a: 1", "a: 1", false},
+		{"html is extracted", "page.html", "<html><body><p>hi</p></body></html>", "hi", false},
+		{"binary is rejected", "image.png", "\x89PNG\r\n\x1a\n\x00\x00", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ExtractReader(strings.NewReader(tt.data), tt.filename)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("got %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
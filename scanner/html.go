@@ -0,0 +1,273 @@
+package scanner
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+// htmlRawText are the elements whose contents are not markup and not
+// document text. Their contents are dropped.
+var htmlRawText = map[string]bool{
+	"script": true, "style": true, "xmp": true, "iframe": true,
+	"noembed": true, "noframes": true,
+}
+
+// htmlRCData are the elements whose contents are text without markup, in
+// which only entities are decoded.
+var htmlRCData = map[string]bool{"title": true, "textarea": true}
+
+// htmlBlocks are the elements that break the flow of text. Their tags
+// separate the text around them with a space.
+var htmlBlocks = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"body": true, "br": true, "caption": true, "dd": true, "details": true,
+	"div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true,
+	"figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "head": true,
+	"header": true, "hr": true, "html": true, "img": true, "input": true,
+	"li": true, "main": true, "meta": true, "nav": true, "ol": true,
+	"option": true, "p": true, "pre": true, "section": true, "select": true,
+	"summary": true, "table": true, "tbody": true, "td": true, "tfoot": true,
+	"th": true, "thead": true, "title": true, "tr": true, "ul": true,
+}
+
+// htmlTextAttrs are the attributes whose values are text shown to readers,
+// included with ExtractOptions.HTMLAttributes. The content attribute is
+// only included on meta elements.
+var htmlTextAttrs = map[string]bool{
+	"alt": true, "title": true, "aria-label": true, "placeholder": true,
+}
+
+// htmlExtractor writes the text of an HTML document so that each line of
+// the text comes from the same line of the source. Markup and dropped
+// content leave their line breaks behind.
+type htmlExtractor struct {
+	opts ExtractOptions
+	b    bytes.Buffer
+}
+
+// extractHTMLText extracts the text of an HTML document. Tags, comments and
+// the contents of scripts and styles are dropped, character references
+// are decoded, and line N of the text holds the text of line N of the
+// source, so that findings report source line numbers. Attribute values
+// and comments are included when opts asks for them.
+func extractHTMLText(data []byte, opts ExtractOptions) (string, error) {
+	x := &htmlExtractor{opts: opts}
+	for i := 0; i < len(data); {
+		if data[i] != '<' {
+			end := bytes.IndexByte(data[i:], '<')
+			if end < 0 {
+				end = len(data) - i
+			}
+			x.text(data[i : i+end])
+			i += end
+			continue
+		}
+		rest := data[i:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			i = x.comment(data, i)
+		case len(rest) > 1 && (rest[1] == '!' || rest[1] == '?'):
+			// A doctype, CDATA section or processing instruction.
+			end := bytes.IndexByte(rest, '>')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			x.skip(rest[:end+1])
+			i += end + 1
+		case len(rest) > 2 && rest[1] == '/' && isASCIILetter(rest[2]):
+			name, end := htmlTagName(rest, 2)
+			close := bytes.IndexByte(rest[end:], '>')
+			if close < 0 {
+				close = len(rest) - end - 1
+			}
+			x.separate(name)
+			x.skip(rest[:end+close+1])
+			i += end + close + 1
+		case len(rest) > 1 && isASCIILetter(rest[1]):
+			i = x.startTag(data, i)
+		default:
+			x.text(rest[:1])
+			i++
+		}
+	}
+	return normalizeHTMLText(x.b.String()), nil
+}
+
+// comment handles the comment starting at data[i] and returns the index
+// after it.
+func (x *htmlExtractor) comment(data []byte, i int) int {
+	start := i + len("<!--")
+	end := len(data)
+	next := len(data)
+	if j := bytes.Index(data[start:], []byte("-->")); j >= 0 {
+		end, next = start+j, start+j+len("-->")
+	}
+	// "<!-->" and "<!--->" are empty comments.
+	if bytes.HasPrefix(data[start:], []byte(">")) {
+		end, next = start, start+1
+	} else if bytes.HasPrefix(data[start:], []byte("->")) {
+		end, next = start, start+2
+	}
+	if x.opts.HTMLComments {
+		x.separate("br")
+		x.text(data[start:end])
+		x.separate("br")
+	} else {
+		x.skip(data[start:end])
+	}
+	return next
+}
+
+// startTag handles the start tag at data[i], its attributes, and the
+// contents of raw text and RCDATA elements. It returns the index after
+// them.
+func (x *htmlExtractor) startTag(data []byte, i int) int {
+	name, pos := htmlTagName(data[i:], 1)
+	pos += i
+	x.separate(name)
+	// Attributes, until the end of the tag.
+	for pos < len(data) && data[pos] != '>' {
+		c := data[pos]
+		if isHTMLSpace(c) || c == '/' {
+			if c == '\n' {
+				x.b.WriteByte('\n')
+			}
+			pos++
+			continue
+		}
+		start := pos
+		for pos < len(data) && !isHTMLSpace(data[pos]) && data[pos] != '/' && data[pos] != '>' && (data[pos] != '=' || pos == start) {
+			pos++
+		}
+		attr := strings.ToLower(string(data[start:pos]))
+		for pos < len(data) && isHTMLSpace(data[pos]) {
+			if data[pos] == '\n' {
+				x.b.WriteByte('\n')
+			}
+			pos++
+		}
+		if pos >= len(data) || data[pos] != '=' {
+			continue
+		}
+		pos++
+		for pos < len(data) && isHTMLSpace(data[pos]) {
+			if data[pos] == '\n' {
+				x.b.WriteByte('\n')
+			}
+			pos++
+		}
+		var value []byte
+		if pos < len(data) && (data[pos] == '"' || data[pos] == '\'') {
+			quote := data[pos]
+			end := bytes.IndexByte(data[pos+1:], quote)
+			if end < 0 {
+				end = len(data) - pos - 1
+			}
+			value = data[pos+1 : pos+1+end]
+			pos += end + 2
+		} else {
+			start := pos
+			for pos < len(data) && !isHTMLSpace(data[pos]) && data[pos] != '>' {
+				pos++
+			}
+			value = data[start:pos]
+		}
+		wanted := htmlTextAttrs[attr] || attr == "content" && name == "meta"
+		if x.opts.HTMLAttributes && wanted {
+			x.separate("br")
+			x.text(value)
+			x.separate("br")
+		} else {
+			x.skip(value)
+		}
+	}
+	pos = min(pos+1, len(data))
+
+	if !htmlRawText[name] && !htmlRCData[name] {
+		return pos
+	}
+	// The contents run to the matching end tag.
+	end := len(data)
+	for j := pos; j < len(data); {
+		k := bytes.Index(data[j:], []byte("</"))
+		if k < 0 {
+			break
+		}
+		j += k
+		if tag, _ := htmlTagName(data[j:], 2); tag == name {
+			end = j
+			break
+		}
+		j += 2
+	}
+	if htmlRCData[name] {
+		x.text(data[pos:end])
+	} else {
+		x.skip(data[pos:end])
+	}
+	return end
+}
+
+// text writes raw text with its character references decoded. References
+// that decode to line breaks become spaces so that lines stay aligned
+// with the source.
+func (x *htmlExtractor) text(raw []byte) {
+	for i, line := range bytes.Split(raw, []byte("\n")) {
+		if i > 0 {
+			x.b.WriteByte('\n')
+		}
+		decoded := html.UnescapeString(string(line))
+		x.b.WriteString(strings.Map(func(r rune) rune {
+			switch r {
+			case '\n', '\r', '\f', '\u00a0':
+				return ' '
+			}
+			return r
+		}, decoded))
+	}
+}
+
+// skip drops raw markup, keeping its line breaks.
+func (x *htmlExtractor) skip(raw []byte) {
+	for n := bytes.Count(raw, []byte("\n")); n > 0; n-- {
+		x.b.WriteByte('\n')
+	}
+}
+
+// separate writes a space at the tags of block elements, so that the text
+// of neighbouring blocks on one line does not run together.
+func (x *htmlExtractor) separate(name string) {
+	if htmlBlocks[name] {
+		x.b.WriteByte(' ')
+	}
+}
+
+// htmlTagName returns the lower-cased tag name starting at data[i] and the
+// index after it.
+func htmlTagName(data []byte, i int) (string, int) {
+	start := i
+	for i < len(data) && !isHTMLSpace(data[i]) && data[i] != '/' && data[i] != '>' {
+		i++
+	}
+	return strings.ToLower(string(data[start:i])), i
+}
+
+func isHTMLSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '\r', '\f':
+		return true
+	}
+	return false
+}
+
+// normalizeHTMLText collapses the whitespace within each line and trims the
+// lines, keeping every line break.
+func normalizeHTMLText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		opts ExtractOptions
+		want string
+	}{
+		{
+			"entities",
+			`<p>&#83;ECRET &amp; &lt;b&gt; caf&eacute; &#x41;&#X42; &euro;5&nbsp;000 &copy &unknown;</p>`,
+			ExtractOptions{},
+			"SECRET & <b> café AB €5 000 © &unknown;",
+		},
+		{
+			"scripts and styles",
+			`<head><STYLE type="text/css">p { content: "</p>" }</STYLE><title>Q&amp;A</title></head>` +
+				`<body><script>var s = "<b>key</b>";</script >visible<Script src=x></Script></body>`,
+			ExtractOptions{},
+			"Q&A visible",
+		},
+		{
+			"blocks and inline elements",
+			`<ul><li>one</li><li>t<b>w</b>o</li></ul><p>a < b and c<br>d</p>`,
+			ExtractOptions{},
+			"one two a < b and c d",
+		},
+		{
+			"comments and attributes dropped",
+			`<!DOCTYPE html><!-- api_key=abc --><img alt="Raccoon" src=r.png><meta name=description content='hidden'>text`,
+			ExtractOptions{},
+			"text",
+		},
+		{
+			"attributes",
+			`<img alt="Raccoon &amp; co" src=r.png><a title=tip href="/x">link</a><meta name=description content='hidden'><p content="no">x</p>`,
+			ExtractOptions{HTMLAttributes: true},
+			"Raccoon & co tip link hidden x",
+		},
+		{
+			"comments",
+			`before<!-- api_key=abc -->after<!---->end`,
+			ExtractOptions{HTMLComments: true},
+			"before api_key=abc after end",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractTextWith([]byte(tt.html), "page.html", tt.opts)
+			if err != nil {
+				t.Fatalf("ExtractText failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExtractHTMLLines(t *testing.T) {
+	html := "<html>\n<head>\n<style>\nbody {}\n</style>\n</head>\n<body>\n<!--\nnote\n-->\n<p\n class=x>first\nline &#10; three</p>\n<img alt=\"a\nb\">\nlast\n</body>\n</html>\n"
+
+	text, err := ExtractText([]byte(html), "page.html")
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	lines := strings.Split(text, "\n")
+	want := map[int]string{12: "first", 13: "line three", 16: "last"}
+	for n, line := range lines {
+		if line != want[n+1] {
+			t.Errorf("Line %d: expected %q, got %q", n+1, want[n+1], line)
+		}
+	}
+	if len(lines) != 16 {
+		t.Errorf("Expected 16 lines, got %d", len(lines))
+	}
+
+	text, err = ExtractTextWith([]byte(html), "page.html", ExtractOptions{HTMLAttributes: true, HTMLComments: true})
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	lines = strings.Split(text, "\n")
+	if len(lines) != 16 || lines[8] != "note" || lines[13] != "a" || lines[14] != "b" {
+		t.Errorf("Unexpected lines with comments and attributes: %q", lines)
+	}
+}
@@ -0,0 +1,223 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"dws/engine"
+)
+
+var errInvalidODF = errors.New("invalid OpenDocument document")
+
+// OpenDocument namespaces.
+const (
+	odfOfficeNS = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odfTextNS   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odfTableNS  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odfDrawNS   = "urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"
+	odfStyleNS  = "urn:oasis:names:tc:opendocument:xmlns:style:1.0"
+	odfDCNS     = "http://purl.org/dc/elements/1.1/"
+)
+
+// maxODFRepeat caps how often a repeated spreadsheet cell is written, since
+// rows commonly end with a cell repeated to the last column.
+const maxODFRepeat = 256
+
+// extractODFText extracts the text of an OpenDocument text document,
+// spreadsheet or presentation: the page headers from styles.xml, the
+// content.xml body including annotations and tracked deletions, then the
+// page footers. Sheets and slides are separated by engine.PageBreak, and
+// sheets have a line per row with cells separated by tabs.
+func extractODFText(ctx context.Context, data []byte) (string, error) {
+	a, err := openArchive(ctx, data, errInvalidODF)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := a.readFile("META-INF/manifest.xml")
+	if err != nil {
+		return "", err
+	}
+	if bytes.Contains(manifest, []byte("encryption-data")) {
+		return "", fmt.Errorf("%w: password-protected OpenDocument files are not supported", ErrEncryptedDocument)
+	}
+	content, err := a.readFile("content.xml")
+	if err != nil {
+		return "", err
+	}
+	if content == nil {
+		return "", fmt.Errorf("%w: missing content.xml", errInvalidODF)
+	}
+	pages, err := odfText(content, nil)
+	if err != nil {
+		return "", err
+	}
+
+	styles, err := a.readFile("styles.xml")
+	if err != nil {
+		return "", err
+	}
+	var headers, footers []string
+	if styles != nil {
+		if headers, err = odfText(styles, odfMasterParts("header")); err != nil {
+			return "", err
+		}
+		if footers, err = odfText(styles, odfMasterParts("footer")); err != nil {
+			return "", err
+		}
+	}
+	return joinNonEmpty(strings.Join(headers, "\n"), strings.Join(pages, "\n"+engine.PageBreak), strings.Join(footers, "\n")), nil
+}
+
+// odfMasterParts returns a filter for the headers or footers of master
+// pages, including their left and first page variants.
+func odfMasterParts(part string) func(xml.Name) bool {
+	return func(name xml.Name) bool {
+		return name.Space == odfStyleNS && (name.Local == part || name.Local == part+"-left" || name.Local == part+"-first")
+	}
+}
+
+// odfText returns the text of the paragraphs of an OpenDocument XML part,
+// a string per sheet or slide. When include is not nil, only the text of
+// elements it accepts is returned, as a single page.
+func odfText(data []byte, include func(xml.Name) bool) ([]string, error) {
+	var pages []string
+	var b strings.Builder
+	var stack []xml.Name
+	included := 0 // open elements accepted by include
+	para := 0     // open paragraphs and headings
+	sheet := false
+
+	// Spreadsheet cells collect into row before being written.
+	var row []string
+	var cell strings.Builder
+	inCell, repeat := false, 1
+
+	write := func(s string) {
+		if para == 0 || include != nil && included == 0 {
+			return
+		}
+		if inCell {
+			cell.WriteString(s)
+			return
+		}
+		b.WriteString(s)
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidODF, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			// Annotations name their author and date.
+			if t.Name.Space == odfDCNS {
+				if err := d.Skip(); err != nil {
+					return nil, fmt.Errorf("%w: %v", errInvalidODF, err)
+				}
+				continue
+			}
+			stack = append(stack, t.Name)
+			if include != nil && include(t.Name) {
+				included++
+			}
+			switch t.Name {
+			case xml.Name{Space: odfOfficeNS, Local: "spreadsheet"}:
+				sheet = true
+			case xml.Name{Space: odfTextNS, Local: "p"}, xml.Name{Space: odfTextNS, Local: "h"}:
+				para++
+			case xml.Name{Space: odfTextNS, Local: "s"}:
+				n, err := strconv.Atoi(odfAttr(t, "c"))
+				if err != nil || n < 1 {
+					n = 1
+				}
+				write(strings.Repeat(" ", min(n, maxODFRepeat)))
+			case xml.Name{Space: odfTextNS, Local: "tab"}:
+				write("\t")
+			case xml.Name{Space: odfTextNS, Local: "line-break"}:
+				write("\n")
+			case xml.Name{Space: odfOfficeNS, Local: "annotation"}:
+				// Annotations sit inside the paragraph they comment on.
+				write("\n")
+			case xml.Name{Space: odfTableNS, Local: "table-row"}:
+				row = row[:0]
+			case xml.Name{Space: odfTableNS, Local: "table-cell"}, xml.Name{Space: odfTableNS, Local: "covered-table-cell"}:
+				if sheet {
+					inCell = true
+					cell.Reset()
+					repeat, err = strconv.Atoi(odfAttr(t, "number-columns-repeated"))
+					if err != nil || repeat < 1 {
+						repeat = 1
+					}
+				}
+			}
+		case xml.EndElement:
+			name := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			var parent xml.Name
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			switch name {
+			case xml.Name{Space: odfTextNS, Local: "p"}, xml.Name{Space: odfTextNS, Local: "h"}:
+				// Paragraphs within a cell are joined by spaces.
+				if inCell {
+					write(" ")
+				} else {
+					write("\n")
+				}
+				para--
+			case xml.Name{Space: odfTableNS, Local: "table-cell"}, xml.Name{Space: odfTableNS, Local: "covered-table-cell"}:
+				if inCell {
+					value := strings.TrimSpace(cell.String())
+					for i := 0; i < min(repeat, maxODFRepeat); i++ {
+						row = append(row, value)
+					}
+					inCell = false
+				}
+			case xml.Name{Space: odfTableNS, Local: "table-row"}:
+				if sheet {
+					if line := strings.TrimRight(strings.Join(row, "\t"), "\t"); line != "" {
+						b.WriteString(line)
+						b.WriteByte('\n')
+					}
+				}
+			case xml.Name{Space: odfTableNS, Local: "table"}, xml.Name{Space: odfDrawNS, Local: "page"}:
+				// A sheet of a spreadsheet or a slide of a presentation.
+				if parent.Space == odfOfficeNS && (parent.Local == "spreadsheet" || parent.Local == "presentation") {
+					pages = append(pages, strings.TrimRight(b.String(), "\n"))
+					b.Reset()
+				}
+			}
+			if include != nil && include(name) {
+				included--
+			}
+		case xml.CharData:
+			write(string(t))
+		}
+	}
+	if text := strings.TrimRight(b.String(), "\n"); text != "" || len(pages) == 0 {
+		pages = append(pages, text)
+	}
+	return pages, nil
+}
+
+// odfAttr returns the value of the attribute named local of an element.
+func odfAttr(se xml.StartElement, local string) string {
+	for _, attr := range se.Attr {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
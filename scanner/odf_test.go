@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"errors"
+	"testing"
+
+	"dws/engine"
+)
+
+const odfNS = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" xmlns:presentation="urn:oasis:names:tc:opendocument:xmlns:presentation:1.0" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/"`
+
+func TestExtractODT(t *testing.T) {
+	data := buildZip(t,
+		"mimetype", "application/vnd.oasis.opendocument.text",
+		"META-INF/manifest.xml", `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0"/>`,
+		"content.xml", `<office:document-content `+odfNS+`><office:body><office:text>
+<text:tracked-changes><text:changed-region text:id="c1"><text:deletion><text:p>removed codename</text:p></text:deletion></text:changed-region></text:tracked-changes>
+<text:h text:outline-level="1">Operation <text:span>Raccoon</text:span></text:h>
+<text:p>Budget:<text:s text:c="3"/>5&amp;10<text:tab/>approved<text:line-break/>next line<office:annotation><dc:creator>Bob</dc:creator><dc:date>2024-01-01</dc:date><text:p>check this</text:p></office:annotation></text:p>
+</office:text></office:body></office:document-content>`,
+		"styles.xml", `<office:document-styles `+odfNS+`><office:master-styles><style:master-page style:name="Standard"><style:header><text:p>SECRET</text:p></style:header><style:footer-left><text:p>Page footer</text:p></style:footer-left></style:master-page></office:master-styles></office:document-styles>`,
+	)
+
+	text, err := ExtractText(data, "memo.odt")
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	want := "SECRET\nremoved codename\nOperation Raccoon\nBudget:   5&10\tapproved\nnext line\ncheck this\nPage footer"
+	if text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+}
+
+func TestExtractODS(t *testing.T) {
+	data := buildZip(t,
+		"content.xml", `<office:document-content `+odfNS+`><office:body><office:spreadsheet>
+<table:table table:name="Cards">
+<table:table-row><table:table-cell><text:p>Name</text:p></table:table-cell><table:table-cell table:number-columns-repeated="2"/><table:table-cell><text:p>Card</text:p></table:table-cell><table:table-cell table:number-columns-repeated="16380"/></table:table-row>
+<table:table-row table:number-rows-repeated="3"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+<table:table-row><table:table-cell><text:p>alice</text:p><text:p>smith</text:p></table:table-cell><table:table-cell table:number-columns-repeated="2"><text:p>x</text:p></table:table-cell></table:table-row>
+</table:table>
+<table:table table:name="Empty"/>
+<table:table table:name="Summary"><table:table-row><table:table-cell><text:p>CONFIDENTIAL</text:p></table:table-cell></table:table-row></table:table>
+</office:spreadsheet></office:body></office:document-content>`,
+	)
+
+	text, err := ExtractText(data, "cards.ods")
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	want := "Name\t\t\tCard\nalice smith\tx\tx\n" + engine.PageBreak + "\n" + engine.PageBreak + "CONFIDENTIAL"
+	if text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+}
+
+func TestExtractODP(t *testing.T) {
+	data := buildZip(t,
+		"content.xml", `<office:document-content `+odfNS+`><office:body><office:presentation>
+<draw:page draw:name="page1"><draw:frame><draw:text-box><text:p>Title slide</text:p></draw:text-box></draw:frame></draw:page>
+<draw:page draw:name="page2"><draw:frame><draw:text-box><text:p>Roadmap</text:p><text:list><text:list-item><text:p>Launch</text:p></text:list-item></text:list></draw:text-box></draw:frame>
+<presentation:notes><draw:frame><draw:text-box><text:p>Do not mention the raccoon</text:p></draw:text-box></draw:frame></presentation:notes></draw:page>
+</office:presentation></office:body></office:document-content>`,
+	)
+
+	text, err := ExtractText(data, "deck.odp")
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	want := "Title slide\n" + engine.PageBreak + "Roadmap\nLaunch\nDo not mention the raccoon"
+	if text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+}
+
+func TestExtractODFErrors(t *testing.T) {
+	encrypted := buildZip(t,
+		"META-INF/manifest.xml", `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0"><manifest:file-entry manifest:full-path="content.xml"><manifest:encryption-data manifest:checksum-type="SHA1"/></manifest:file-entry></manifest:manifest>`,
+		"content.xml", "\x8f\x12garbage",
+	)
+	if _, err := ExtractText(encrypted, "secret.odt"); !errors.Is(err, ErrEncryptedDocument) {
+		t.Errorf("Expected encrypted document error, got %v", err)
+	}
+	if _, err := ExtractText([]byte("not a zip"), "file.ods"); !errors.Is(err, errInvalidODF) {
+		t.Errorf("Expected invalid document error, got %v", err)
+	}
+	if _, err := ExtractText(buildZip(t, "styles.xml", "<x/>"), "file.odp"); !errors.Is(err, errInvalidODF) {
+		t.Errorf("Expected missing content error, got %v", err)
+	}
+}
@@ -0,0 +1,458 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"dws/engine"
+)
+
+var errInvalidOOXML = errors.New("invalid Office Open XML document")
+
+// Relationship types, matched by suffix so that both transitional and
+// strict OOXML namespaces are recognized.
+const (
+	relOfficeDocument = "/officeDocument"
+	relHeader         = "/header"
+	relFooter         = "/footer"
+	relFootnotes      = "/footnotes"
+	relEndnotes       = "/endnotes"
+	relComments       = "/comments"
+	relSharedStrings  = "/sharedStrings"
+	relNotesSlide     = "/notesSlide"
+)
+
+// ooxmlRel is a relationship from a part, with its target resolved to a
+// name within the package.
+type ooxmlRel struct {
+	id, typ, target string
+}
+
+// extractOOXMLText extracts the text of a Word, Excel or PowerPoint
+// document. Worksheets and slides are separated by engine.PageBreak, so
+// findings report the sheet or slide as their page.
+func extractOOXMLText(ctx context.Context, data []byte, ext string) (string, error) {
+	a, err := openArchive(ctx, data, errInvalidOOXML)
+	if err != nil {
+		return "", err
+	}
+	switch ext {
+	case ".xlsx", ".xlsm":
+		return extractXLSX(a)
+	case ".pptx", ".pptm":
+		return extractPPTX(a)
+	default:
+		return extractDOCX(a)
+	}
+}
+
+// extractDOCX extracts the headers, body, footers, footnotes, endnotes and
+// comments of a Word document, including tracked insertions and deletions.
+func extractDOCX(a *archive) (string, error) {
+	main := a.mainPart("word/document.xml")
+	rels := a.rels(main)
+	parts := relTargets(rels, relHeader)
+	parts = append(parts, main)
+	for _, typ := range []string{relFooter, relFootnotes, relEndnotes, relComments} {
+		parts = append(parts, relTargets(rels, typ)...)
+	}
+	var texts []string
+	for _, part := range parts {
+		data, err := a.readFile(part)
+		if err != nil {
+			return "", err
+		}
+		if data == nil {
+			if part == main {
+				return "", fmt.Errorf("%w: missing %s", errInvalidOOXML, main)
+			}
+			continue
+		}
+		text, err := ooxmlText(data)
+		if err != nil {
+			return "", err
+		}
+		texts = append(texts, text)
+	}
+	return joinNonEmpty(texts...), nil
+}
+
+// extractXLSX extracts the cells and comments of each worksheet of a
+// workbook, one row per line with cells separated by tabs.
+func extractXLSX(a *archive) (string, error) {
+	main := a.mainPart("xl/workbook.xml")
+	workbook, err := a.readFile(main)
+	if err != nil {
+		return "", err
+	}
+	if workbook == nil {
+		return "", fmt.Errorf("%w: missing %s", errInvalidOOXML, main)
+	}
+	rels := a.rels(main)
+	var shared []string
+	if targets := relTargets(rels, relSharedStrings); len(targets) > 0 {
+		data, err := a.readFile(targets[0])
+		if err != nil {
+			return "", err
+		}
+		if shared, err = sharedStrings(data); err != nil {
+			return "", err
+		}
+	}
+	sheets, err := relOrder(workbook, "sheet", rels)
+	if err != nil {
+		return "", err
+	}
+	if len(sheets) == 0 {
+		sheets = a.glob("xl/worksheets/sheet*.xml")
+	}
+
+	pages := make([]string, 0, len(sheets))
+	for _, sheet := range sheets {
+		data, err := a.readFile(sheet)
+		if err != nil {
+			return "", err
+		}
+		text, err := sheetText(data, shared)
+		if err != nil {
+			return "", err
+		}
+		comments, err := a.relText(sheet, relComments)
+		if err != nil {
+			return "", err
+		}
+		pages = append(pages, joinNonEmpty(text, comments))
+	}
+	return strings.Join(pages, "\n"+engine.PageBreak), nil
+}
+
+// extractPPTX extracts the text, speaker notes and comments of each slide
+// of a presentation, in slide order.
+func extractPPTX(a *archive) (string, error) {
+	main := a.mainPart("ppt/presentation.xml")
+	presentation, err := a.readFile(main)
+	if err != nil {
+		return "", err
+	}
+	if presentation == nil {
+		return "", fmt.Errorf("%w: missing %s", errInvalidOOXML, main)
+	}
+	slides, err := relOrder(presentation, "sldId", a.rels(main))
+	if err != nil {
+		return "", err
+	}
+	if len(slides) == 0 {
+		slides = a.glob("ppt/slides/slide*.xml")
+	}
+
+	pages := make([]string, 0, len(slides))
+	for _, slide := range slides {
+		data, err := a.readFile(slide)
+		if err != nil {
+			return "", err
+		}
+		text, err := ooxmlText(data)
+		if err != nil {
+			return "", err
+		}
+		notes, err := a.relText(slide, relNotesSlide)
+		if err != nil {
+			return "", err
+		}
+		comments, err := a.relText(slide, relComments)
+		if err != nil {
+			return "", err
+		}
+		pages = append(pages, joinNonEmpty(text, notes, comments))
+	}
+	return strings.Join(pages, "\n"+engine.PageBreak), nil
+}
+
+// mainPart returns the name of the package's main document, named by the
+// package relationships, or fallback.
+func (a *archive) mainPart(fallback string) string {
+	if targets := relTargets(a.rels(""), relOfficeDocument); len(targets) > 0 {
+		return targets[0]
+	}
+	return fallback
+}
+
+// rels returns the relationships of part, or of the package itself when
+// part is empty. Missing or malformed relationships are treated as none.
+func (a *archive) rels(part string) []ooxmlRel {
+	dir, base := path.Split(part)
+	data, err := a.readFile(dir + "_rels/" + base + ".rels")
+	if err != nil || data == nil {
+		return nil
+	}
+	var doc struct {
+		Rels []struct {
+			ID         string `xml:"Id,attr"`
+			Type       string `xml:"Type,attr"`
+			Target     string `xml:"Target,attr"`
+			TargetMode string `xml:"TargetMode,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+	rels := make([]ooxmlRel, 0, len(doc.Rels))
+	for _, r := range doc.Rels {
+		if r.TargetMode == "External" {
+			continue
+		}
+		target := strings.TrimPrefix(r.Target, "/")
+		if !strings.HasPrefix(r.Target, "/") {
+			target = path.Join(path.Dir(part), r.Target)
+		}
+		rels = append(rels, ooxmlRel{id: r.ID, typ: r.Type, target: target})
+	}
+	return rels
+}
+
+// relTargets returns the targets of the relationships of a type, in
+// natural order.
+func relTargets(rels []ooxmlRel, typ string) []string {
+	var targets []string
+	for _, r := range rels {
+		if strings.HasSuffix(r.typ, typ) {
+			targets = append(targets, r.target)
+		}
+	}
+	sortParts(targets)
+	return targets
+}
+
+// relText returns the text of the parts related to part by a type.
+func (a *archive) relText(part, typ string) (string, error) {
+	var texts []string
+	for _, target := range relTargets(a.rels(part), typ) {
+		data, err := a.readFile(target)
+		if err != nil {
+			return "", err
+		}
+		text, err := ooxmlText(data)
+		if err != nil {
+			return "", err
+		}
+		texts = append(texts, text)
+	}
+	return joinNonEmpty(texts...), nil
+}
+
+// relOrder returns the targets of the elements named local in data, such
+// as the sheets of a workbook, in document order by their relationship ID.
+func relOrder(data []byte, local string, rels []ooxmlRel) ([]string, error) {
+	byID := make(map[string]string, len(rels))
+	for _, r := range rels {
+		byID[r.id] = r.target
+	}
+	var targets []string
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return targets, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidOOXML, err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == local {
+			for _, attr := range se.Attr {
+				// The relationship ID is the only "id" attribute in the
+				// relationships namespace; sldId also has a plain id.
+				if attr.Name.Local == "id" && attr.Name.Space != "" {
+					if target, ok := byID[attr.Value]; ok {
+						targets = append(targets, target)
+					}
+				}
+			}
+		}
+	}
+}
+
+// ooxmlText returns the text of a WordprocessingML, DrawingML or
+// SpreadsheetML comments part: the contents of its text elements, with a
+// line per paragraph or comment. Field codes, alternate content fallbacks
+// and phonetic guides, which repeat text, are skipped.
+func ooxmlText(data []byte) (string, error) {
+	var b strings.Builder
+	var inText []bool
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", errInvalidOOXML, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "Fallback", "instrText", "delInstrText", "pPr", "rPr", "rPh":
+				if err := d.Skip(); err != nil {
+					return "", fmt.Errorf("%w: %v", errInvalidOOXML, err)
+				}
+				continue
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			case "noBreakHyphen":
+				b.WriteByte('-')
+			}
+			// "text" holds the text of legacy PowerPoint comments.
+			local := t.Name.Local
+			inText = append(inText, local == "t" || local == "delText" || local == "text")
+		case xml.EndElement:
+			inText = inText[:len(inText)-1]
+			switch t.Name.Local {
+			case "p", "comment", "cm":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if len(inText) > 0 && inText[len(inText)-1] {
+				b.Write(t)
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// sharedStrings returns the shared strings table of a workbook.
+func sharedStrings(data []byte) ([]string, error) {
+	var strs []string
+	var b strings.Builder
+	inText := false
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return strs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidOOXML, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				b.Reset()
+			case "t":
+				inText = true
+			case "rPh":
+				if err := d.Skip(); err != nil {
+					return nil, fmt.Errorf("%w: %v", errInvalidOOXML, err)
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				strs = append(strs, b.String())
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+}
+
+// sheetText returns the cell values of a worksheet, a line per row with
+// cells separated by tabs. Formulas are skipped in favour of their cached
+// values.
+func sheetText(data []byte, shared []string) (string, error) {
+	var b strings.Builder
+	var row []string
+	var cell strings.Builder
+	var cellType string
+	inValue := false
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", errInvalidOOXML, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row = row[:0]
+			case "c":
+				cell.Reset()
+				cellType = ""
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "t" {
+						cellType = attr.Value
+					}
+				}
+			case "v", "t":
+				inValue = true
+			case "f", "rPh":
+				if err := d.Skip(); err != nil {
+					return "", fmt.Errorf("%w: %v", errInvalidOOXML, err)
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				row = append(row, cellValue(cellType, cell.String(), shared))
+			case "row":
+				line := strings.TrimRight(strings.Join(row, "\t"), "\t")
+				if line != "" {
+					b.WriteString(line)
+					b.WriteByte('\n')
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				cell.Write(t)
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// cellValue returns the text of a cell of type typ holding value.
+func cellValue(typ, value string, shared []string) string {
+	switch typ {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "b":
+		if strings.TrimSpace(value) == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return value
+}
+
+// joinNonEmpty joins the non-empty texts with newlines.
+func joinNonEmpty(texts ...string) string {
+	var parts []string
+	for _, t := range texts {
+		if t != "" {
+			parts = append(parts, t)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,160 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"dws/engine"
+)
+
+// buildZip returns a ZIP archive holding files, in the given order.
+func buildZip(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for i := 0; i+1 < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		if err != nil {
+			t.Fatalf("create %s: %v", files[i], err)
+		}
+		w.Write([]byte(files[i+1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return b.Bytes()
+}
+
+// rels returns a relationships part with (id, type, target) triples; types
+// are relative to the officeDocument relationships namespace.
+func rels(triples ...string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 0; i+2 < len(triples); i += 3 {
+		b.WriteString(`<Relationship Id="` + triples[i] + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/` + triples[i+1] + `" Target="` + triples[i+2] + `"/>`)
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+const (
+	wordNS  = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006"`
+	sheetNS = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+	slideNS = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+)
+
+func TestExtractDOCX(t *testing.T) {
+	data := buildZip(t,
+		"_rels/.rels", rels("rId1", "officeDocument", "word/document.xml"),
+		"word/_rels/document.xml.rels", rels(
+			"rId1", "header", "header1.xml",
+			"rId2", "footer", "footer1.xml",
+			"rId3", "comments", "comments.xml",
+			"rId4", "footnotes", "footnotes.xml",
+		),
+		"word/document.xml", `<w:document `+wordNS+`><w:body>
+<w:p><w:pPr><w:tabs><w:tab w:val="left" w:pos="720"/></w:tabs></w:pPr><w:r><w:t>Project</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">Raccoon </w:t></w:r><w:ins><w:r><w:t>launch</w:t></w:r></w:ins><w:del><w:r><w:delText>cancelled</w:delText></w:r></w:del></w:p>
+<w:p><w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText>PAGE</w:instrText></w:r><w:r><w:t>Budget: 5&amp;10</w:t><w:br/><w:t>next line</w:t></w:r></w:p>
+<w:p><mc:AlternateContent><mc:Choice Requires="wps"><w:r><w:t>in a text box</w:t></w:r></mc:Choice><mc:Fallback><w:r><w:t>in a text box</w:t></w:r></mc:Fallback></mc:AlternateContent></w:p>
+</w:body></w:document>`,
+		"word/header1.xml", `<w:hdr `+wordNS+`><w:p><w:r><w:t>TOP SECRET</w:t></w:r></w:p></w:hdr>`,
+		"word/footer1.xml", `<w:ftr `+wordNS+`><w:p><w:r><w:t>Page footer</w:t></w:r></w:p></w:ftr>`,
+		"word/footnotes.xml", `<w:footnotes `+wordNS+`><w:footnote w:id="1"><w:p><w:r><w:t>A footnote</w:t></w:r></w:p></w:footnote></w:footnotes>`,
+		"word/comments.xml", `<w:comments `+wordNS+`><w:comment w:id="0" w:author="Bob"><w:p><w:r><w:t>Remove the codename</w:t></w:r></w:p></w:comment></w:comments>`,
+	)
+
+	text, err := ExtractText(data, "report.docx")
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	want := "TOP SECRET\n" +
+		"Project\tRaccoon launchcancelled\nBudget: 5&10\nnext line\nin a text box\n" +
+		"Page footer\nA footnote\nRemove the codename"
+	if text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+}
+
+func TestExtractXLSX(t *testing.T) {
+	data := buildZip(t,
+		"_rels/.rels", rels("rId1", "officeDocument", "xl/workbook.xml"),
+		"xl/_rels/workbook.xml.rels", rels(
+			"rId1", "worksheet", "worksheets/sheet1.xml",
+			"rId2", "worksheet", "worksheets/sheet2.xml",
+			"rId3", "sharedStrings", "sharedStrings.xml",
+		),
+		// The workbook lists the second sheet file first.
+		"xl/workbook.xml", `<workbook `+sheetNS+`><sheets><sheet name="Summary" sheetId="2" r:id="rId2"/><sheet name="Data" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/sharedStrings.xml", `<sst `+sheetNS+`><si><t>Name</t></si><si><r><t>Card </t></r><r><rPr><b/></rPr><t>number</t></r><rPh><t>カード</t></rPh></si></sst>`,
+		"xl/worksheets/sheet1.xml", `<worksheet `+sheetNS+`><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>alice</t></is></c><c r="B2"><v>4111111111111111</v></c><c r="C2" t="b"><v>1</v></c></row>
+<row r="3"><c r="A3"><f>SUM(B2)</f><v>42</v></c><c r="B3" t="s"/></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/_rels/sheet1.xml.rels", rels("rId1", "comments", "../comments1.xml"),
+		"xl/comments1.xml", `<comments `+sheetNS+`><authors><author>Bob</author></authors><commentList><comment ref="B2" authorId="0"><text><r><t>Bob:</t></r><r><t xml:space="preserve"> real card</t></r></text></comment></commentList></comments>`,
+		"xl/worksheets/sheet2.xml", `<worksheet `+sheetNS+`><sheetData><row r="1"><c r="A1" t="str"><v>CONFIDENTIAL</v></c></row></sheetData></worksheet>`,
+	)
+
+	text, err := ExtractText(data, "cards.xlsx")
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	want := "CONFIDENTIAL\n" + engine.PageBreak +
+		"Name\tCard number\nalice\t4111111111111111\tTRUE\n42\nBob: real card"
+	if text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+}
+
+func TestExtractPPTX(t *testing.T) {
+	slide := func(text string) string {
+		return `<p:sld ` + slideNS + `><p:cSld><p:spTree><p:sp><p:txBody><a:p><a:r><a:t>` + text + `</a:t></a:r><a:br/><a:r><a:t>second line</a:t></a:r></a:p></p:txBody></p:sp></p:spTree></p:cSld></p:sld>`
+	}
+	data := buildZip(t,
+		"_rels/.rels", rels("rId1", "officeDocument", "ppt/presentation.xml"),
+		"ppt/_rels/presentation.xml.rels", rels(
+			"rId2", "slide", "slides/slide2.xml",
+			"rId3", "slide", "slides/slide10.xml",
+		),
+		"ppt/presentation.xml", `<p:presentation `+slideNS+`><p:sldIdLst><p:sldId id="256" r:id="rId3"/><p:sldId id="257" r:id="rId2"/></p:sldIdLst></p:presentation>`,
+		"ppt/slides/slide10.xml", slide("Title slide"),
+		"ppt/slides/slide2.xml", slide("Roadmap"),
+		"ppt/slides/_rels/slide2.xml.rels", rels(
+			"rId1", "notesSlide", "../notesSlides/notesSlide1.xml",
+			"rId2", "comments", "../comments/comment1.xml",
+		),
+		"ppt/notesSlides/notesSlide1.xml", `<p:notes `+slideNS+`><p:cSld><p:spTree><p:sp><p:txBody><a:p><a:r><a:t>Do not mention the raccoon</a:t></a:r></a:p></p:txBody></p:sp></p:spTree></p:cSld></p:notes>`,
+		"ppt/comments/comment1.xml", `<p:cmLst `+slideNS+`><p:cm authorId="0"><p:pos x="10" y="10"/><p:text>Check with legal</p:text></p:cm></p:cmLst>`,
+	)
+
+	text, err := ExtractText(data, "deck.pptx")
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	want := "Title slide\nsecond line\n" + engine.PageBreak +
+		"Roadmap\nsecond line\nDo not mention the raccoon\nCheck with legal"
+	if text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+}
+
+func TestExtractOOXMLErrors(t *testing.T) {
+	encrypted := append(append([]byte{}, oleSignature...), make([]byte, 512)...)
+	if _, err := ExtractText(encrypted, "secret.docx"); !errors.Is(err, ErrEncryptedDocument) {
+		t.Errorf("Expected encrypted document error, got %v", err)
+	}
+	if _, err := ExtractText([]byte("not a zip"), "file.xlsx"); !errors.Is(err, errInvalidOOXML) {
+		t.Errorf("Expected invalid document error, got %v", err)
+	}
+	if _, err := ExtractText(buildZip(t, "other.xml", "<x/>"), "file.docx"); !errors.Is(err, errInvalidOOXML) {
+		t.Errorf("Expected missing document part error, got %v", err)
+	}
+	broken := buildZip(t, "word/document.xml", `<w:document `+wordNS+`><w:body><w:p>`)
+	if _, err := ExtractText(broken, "file.docx"); !errors.Is(err, errInvalidOOXML) {
+		t.Errorf("Expected malformed XML error, got %v", err)
+	}
+}
@@ -0,0 +1,924 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// PDF object types. Integers and reals are int64 and float64, booleans bool
+// and null nil.
+type (
+	pdfName    string
+	pdfString  string
+	pdfKeyword string
+	pdfArray   []any
+	pdfDict    map[pdfName]any
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		raw  []byte
+	}
+)
+
+// maxPDFStreamSize caps the decoded size of a single PDF stream, so that a
+// small compressed document cannot expand without bound.
+const maxPDFStreamSize = 64 << 20
+
+// maxPDFDepth bounds the nesting of PDF objects, page trees and form
+// XObjects, which malformed documents can make cyclic.
+const maxPDFDepth = 64
+
+var errInvalidPDF = errors.New("invalid PDF")
+
+// pdfLexer reads PDF tokens and objects from data.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isPDFDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and comments.
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// token returns the next token: a number, name, string, keyword or one of
+// the delimiters "[", "]", "<<" and ">>" as a pdfKeyword. It returns io.EOF
+// at the end of the data.
+func (l *pdfLexer) token() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		return l.name(), nil
+	case c == '(':
+		return l.literalString(), nil
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return pdfKeyword("<<"), nil
+		}
+		return l.hexString(), nil
+	case c == '>':
+		l.pos++
+		if l.pos < len(l.data) && l.data[l.pos] == '>' {
+			l.pos++
+			return pdfKeyword(">>"), nil
+		}
+		return nil, fmt.Errorf("%w: unexpected '>' at offset %d", errInvalidPDF, l.pos-1)
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(l.data[l.pos-1 : l.pos]), nil
+	case c == ')':
+		l.pos++
+		return nil, fmt.Errorf("%w: unexpected ')' at offset %d", errInvalidPDF, l.pos-1)
+	}
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+	if n, err := strconv.ParseInt(word, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(word, 64); err == nil && (word[0] == '.' || word[0] == '-' || word[0] == '+' || (word[0] >= '0' && word[0] <= '9')) {
+		return f, nil
+	}
+	return pdfKeyword(word), nil
+}
+
+func (l *pdfLexer) name() pdfName {
+	l.pos++
+	var b []byte
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		c := l.data[l.pos]
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		l.pos++
+	}
+	return pdfName(b)
+}
+
+func (l *pdfLexer) literalString() pdfString {
+	l.pos++
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return pdfString(b)
+			}
+		case '\r':
+			// End-of-line markers in strings read as a single newline.
+			if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+				l.pos++
+			}
+			c = '\n'
+		case '\\':
+			if l.pos >= len(l.data) {
+				return pdfString(b)
+			}
+			c = l.data[l.pos]
+			l.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					v := int(c - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				}
+			}
+		}
+		b = append(b, c)
+	}
+	return pdfString(b)
+}
+
+func (l *pdfLexer) hexString() pdfString {
+	l.pos++
+	var b []byte
+	var hi byte
+	half := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			break
+		}
+		v, ok := unhex(c)
+		if !ok {
+			continue
+		}
+		if half {
+			b = append(b, hi<<4|v)
+		} else {
+			hi = v
+		}
+		half = !half
+	}
+	if half {
+		b = append(b, hi<<4)
+	}
+	return pdfString(b)
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// object reads the next object, resolving arrays, dictionaries and
+// indirect references. Keywords other than delimiters are returned as they
+// are, so that callers can find operators and "obj"/"stream" markers.
+func (l *pdfLexer) object() (any, error) {
+	return l.objectDepth(0)
+}
+
+func (l *pdfLexer) objectDepth(depth int) (any, error) {
+	if depth > maxPDFDepth {
+		return nil, fmt.Errorf("%w: objects nested too deeply", errInvalidPDF)
+	}
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case pdfKeyword:
+		switch t {
+		case "[":
+			arr := pdfArray{}
+			for {
+				l.skipSpace()
+				if l.pos < len(l.data) && l.data[l.pos] == ']' {
+					l.pos++
+					return arr, nil
+				}
+				v, err := l.objectDepth(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			}
+		case "<<":
+			dict := pdfDict{}
+			for {
+				tok, err := l.token()
+				if err != nil {
+					return nil, err
+				}
+				if tok == pdfKeyword(">>") {
+					return dict, nil
+				}
+				key, ok := tok.(pdfName)
+				if !ok {
+					return nil, fmt.Errorf("%w: dictionary key is not a name at offset %d", errInvalidPDF, l.pos)
+				}
+				v, err := l.objectDepth(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = v
+			}
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t, nil
+	case int64:
+		// An integer may start an indirect reference "num gen R".
+		save := l.pos
+		if gen, err := l.token(); err == nil {
+			if g, ok := gen.(int64); ok {
+				if r, err := l.token(); err == nil && r == pdfKeyword("R") {
+					return pdfRef{int(t), int(g)}, nil
+				}
+			}
+		}
+		l.pos = save
+		return t, nil
+	}
+	return tok, nil
+}
+
+// xrefEntry locates an object, either at an offset in the file or inside
+// an object stream.
+type xrefEntry struct {
+	offset   int
+	inStream bool
+	stream   int // object stream number when inStream
+	index    int // index within the object stream
+}
+
+// pdfFile is a parsed PDF document.
+type pdfFile struct {
+	data    []byte
+	xref    map[int]xrefEntry
+	trailer pdfDict
+	cache   map[int]any
+	loading map[int]bool
+	streams map[int]*objectStream
+	crypt   *pdfCrypt // nil unless the document is encrypted
+}
+
+// objectStream is a decoded object stream: the offsets of its objects
+// within data.
+type objectStream struct {
+	data    []byte
+	offsets []int
+}
+
+// openPDF parses the cross-reference data of a PDF, falling back to
+// scanning the file for objects when it is missing or damaged.
+func openPDF(data []byte) (*pdfFile, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return nil, fmt.Errorf("%w: missing %%PDF header", errInvalidPDF)
+	}
+	f := &pdfFile{
+		data:    data,
+		xref:    make(map[int]xrefEntry),
+		cache:   make(map[int]any),
+		loading: make(map[int]bool),
+		streams: make(map[int]*objectStream),
+	}
+	if err := f.readXref(); err != nil || f.trailer["Root"] == nil {
+		f.xref = make(map[int]xrefEntry)
+		if err := f.rebuildXref(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+var startxrefPattern = regexp.MustCompile(`startxref\s+(\d+)`)
+
+// readXref reads the cross-reference sections from the last startxref,
+// following /Prev links to older sections. Entries in newer sections win.
+func (f *pdfFile) readXref() error {
+	matches := startxrefPattern.FindAllSubmatch(f.data, -1)
+	if len(matches) == 0 {
+		return fmt.Errorf("%w: missing startxref", errInvalidPDF)
+	}
+	offset, _ := strconv.Atoi(string(matches[len(matches)-1][1]))
+	seen := make(map[int]bool)
+	for offset > 0 && !seen[offset] {
+		seen[offset] = true
+		trailer, err := f.readXrefSection(offset)
+		if err != nil {
+			return err
+		}
+		if f.trailer == nil {
+			f.trailer = trailer
+		}
+		// Hybrid files keep compressed objects in an xref stream named by
+		// the trailer of the classic table.
+		if stm, ok := trailer["XRefStm"].(int64); ok && !seen[int(stm)] {
+			seen[int(stm)] = true
+			if _, err := f.readXrefSection(int(stm)); err != nil {
+				return err
+			}
+		}
+		prev, _ := trailer["Prev"].(int64)
+		offset = int(prev)
+	}
+	return nil
+}
+
+// readXrefSection reads a cross-reference table or stream at offset and
+// returns its trailer dictionary.
+func (f *pdfFile) readXrefSection(offset int) (pdfDict, error) {
+	if offset < 0 || offset >= len(f.data) {
+		return nil, fmt.Errorf("%w: xref offset %d out of range", errInvalidPDF, offset)
+	}
+	l := &pdfLexer{data: f.data, pos: offset}
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == pdfKeyword("xref") {
+		return f.readXrefTable(l)
+	}
+	l.pos = offset
+	_, obj, err := f.readIndirect(l)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := obj.(*pdfStream)
+	if !ok || stream.dict["Type"] != pdfName("XRef") {
+		return nil, fmt.Errorf("%w: no xref at offset %d", errInvalidPDF, offset)
+	}
+	return stream.dict, f.readXrefStream(stream)
+}
+
+func (f *pdfFile) readXrefTable(l *pdfLexer) (pdfDict, error) {
+	for {
+		tok, err := l.token()
+		if err != nil {
+			return nil, err
+		}
+		if tok == pdfKeyword("trailer") {
+			obj, err := l.object()
+			if err != nil {
+				return nil, err
+			}
+			trailer, ok := obj.(pdfDict)
+			if !ok {
+				return nil, fmt.Errorf("%w: trailer is not a dictionary", errInvalidPDF)
+			}
+			return trailer, nil
+		}
+		first, ok := tok.(int64)
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed xref table", errInvalidPDF)
+		}
+		count, err := l.token()
+		n, ok := count.(int64)
+		if err != nil || !ok {
+			return nil, fmt.Errorf("%w: malformed xref table", errInvalidPDF)
+		}
+		for i := int64(0); i < n; i++ {
+			off, err1 := l.token()
+			_, err2 := l.token()
+			kind, err3 := l.token()
+			if err1 != nil || err2 != nil || err3 != nil {
+				return nil, fmt.Errorf("%w: truncated xref table", errInvalidPDF)
+			}
+			num := int(first + i)
+			if _, ok := f.xref[num]; ok || kind != pdfKeyword("n") {
+				continue
+			}
+			if o, ok := off.(int64); ok {
+				f.xref[num] = xrefEntry{offset: int(o)}
+			}
+		}
+	}
+}
+
+func (f *pdfFile) readXrefStream(stream *pdfStream) error {
+	data, err := f.decodeStream(stream)
+	if err != nil {
+		return err
+	}
+	w, _ := stream.dict["W"].(pdfArray)
+	if len(w) < 3 {
+		return fmt.Errorf("%w: xref stream without /W", errInvalidPDF)
+	}
+	var widths [3]int
+	rowLen := 0
+	for i := range widths {
+		n, _ := w[i].(int64)
+		if n < 0 || n > 8 {
+			return fmt.Errorf("%w: bad xref stream /W", errInvalidPDF)
+		}
+		widths[i] = int(n)
+		rowLen += int(n)
+	}
+	if rowLen == 0 {
+		return fmt.Errorf("%w: bad xref stream /W", errInvalidPDF)
+	}
+	index, _ := stream.dict["Index"].(pdfArray)
+	if index == nil {
+		size, _ := stream.dict["Size"].(int64)
+		index = pdfArray{int64(0), size}
+	}
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		first, _ := index[i].(int64)
+		count, _ := index[i+1].(int64)
+		for j := int64(0); j < count; j++ {
+			if pos+rowLen > len(data) {
+				return nil
+			}
+			var fields [3]int
+			for k, width := range widths {
+				for b := 0; b < width; b++ {
+					fields[k] = fields[k]<<8 | int(data[pos])
+					pos++
+				}
+			}
+			if widths[0] == 0 {
+				fields[0] = 1
+			}
+			num := int(first + j)
+			if _, ok := f.xref[num]; ok {
+				continue
+			}
+			switch fields[0] {
+			case 1:
+				f.xref[num] = xrefEntry{offset: fields[1]}
+			case 2:
+				f.xref[num] = xrefEntry{inStream: true, stream: fields[1], index: fields[2]}
+			}
+		}
+	}
+	return nil
+}
+
+var objPattern = regexp.MustCompile(`(?m)(\d+)\s+(\d+)\s+obj\b`)
+
+// rebuildXref recovers a damaged file by indexing every "n g obj" in it.
+// Later definitions win, as they would after an incremental update.
+func (f *pdfFile) rebuildXref() error {
+	for _, m := range objPattern.FindAllSubmatchIndex(f.data, -1) {
+		num, _ := strconv.Atoi(string(f.data[m[2]:m[3]]))
+		f.xref[num] = xrefEntry{offset: m[0]}
+	}
+	f.trailer = pdfDict{}
+	for _, i := range bytesIndexAll(f.data, []byte("trailer")) {
+		l := &pdfLexer{data: f.data, pos: i + len("trailer")}
+		if obj, err := l.object(); err == nil {
+			if d, ok := obj.(pdfDict); ok {
+				for k, v := range d {
+					f.trailer[k] = v
+				}
+			}
+		}
+	}
+	if f.trailer["Root"] != nil {
+		return nil
+	}
+	// Files with only xref streams keep their trailer in the stream.
+	for num := range f.xref {
+		if d, ok := f.resolve(pdfRef{num: num}).(pdfDict); ok && d["Type"] == pdfName("Catalog") {
+			f.trailer["Root"] = pdfRef{num: num}
+			return nil
+		}
+		if s, ok := f.resolve(pdfRef{num: num}).(*pdfStream); ok && s.dict["Type"] == pdfName("XRef") && s.dict["Root"] != nil {
+			f.trailer["Root"] = s.dict["Root"]
+			if enc := s.dict["Encrypt"]; enc != nil {
+				f.trailer["Encrypt"] = enc
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no document catalog", errInvalidPDF)
+}
+
+func bytesIndexAll(data, sep []byte) []int {
+	var idx []int
+	for i := 0; ; {
+		j := bytes.Index(data[i:], sep)
+		if j < 0 {
+			return idx
+		}
+		idx = append(idx, i+j)
+		i += j + len(sep)
+	}
+}
+
+// readIndirect reads "num gen obj ... endobj" at the lexer position,
+// including a following stream.
+func (f *pdfFile) readIndirect(l *pdfLexer) (pdfRef, any, error) {
+	num, err1 := l.token()
+	gen, err2 := l.token()
+	kw, err3 := l.token()
+	n, ok1 := num.(int64)
+	g, ok2 := gen.(int64)
+	if err1 != nil || err2 != nil || err3 != nil || !ok1 || !ok2 || kw != pdfKeyword("obj") {
+		return pdfRef{}, nil, fmt.Errorf("%w: no object at offset %d", errInvalidPDF, l.pos)
+	}
+	ref := pdfRef{num: int(n), gen: int(g)}
+	obj, err := l.object()
+	if err != nil {
+		return pdfRef{}, nil, err
+	}
+	dict, ok := obj.(pdfDict)
+	if !ok {
+		return ref, obj, nil
+	}
+	save := l.pos
+	if tok, err := l.token(); err != nil || tok != pdfKeyword("stream") {
+		l.pos = save
+		return ref, obj, nil
+	}
+	// The stream data starts after the end-of-line following "stream".
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+	start := l.pos
+	end := -1
+	if length, ok := f.resolve(dict["Length"]).(int64); ok && length >= 0 && start+int(length) <= len(l.data) {
+		end = start + int(length)
+		rest := &pdfLexer{data: l.data, pos: end}
+		if tok, err := rest.token(); err != nil || tok != pdfKeyword("endstream") {
+			end = -1
+		}
+	}
+	if end < 0 {
+		// A missing or wrong /Length: fall back to the endstream marker.
+		i := bytes.Index(l.data[start:], []byte("endstream"))
+		if i < 0 {
+			return pdfRef{}, nil, fmt.Errorf("%w: unterminated stream in object %d", errInvalidPDF, n)
+		}
+		end = start + i
+		for end > start && (l.data[end-1] == '\n' || l.data[end-1] == '\r') {
+			end--
+		}
+	}
+	l.pos = end
+	return ref, &pdfStream{dict: dict, raw: l.data[start:end]}, nil
+}
+
+// resolve follows indirect references, returning nil for objects that are
+// missing or cannot be parsed.
+func (f *pdfFile) resolve(v any) any {
+	for depth := 0; depth < maxPDFDepth; depth++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = f.load(ref.num)
+	}
+	return nil
+}
+
+func (f *pdfFile) load(num int) any {
+	if obj, ok := f.cache[num]; ok {
+		return obj
+	}
+	if f.loading[num] {
+		return nil
+	}
+	f.loading[num] = true
+	defer delete(f.loading, num)
+
+	var obj any
+	entry, ok := f.xref[num]
+	switch {
+	case !ok:
+	case entry.inStream:
+		obj = f.loadFromStream(entry)
+	default:
+		if entry.offset >= 0 && entry.offset < len(f.data) {
+			l := &pdfLexer{data: f.data, pos: entry.offset}
+			if ref, o, err := f.readIndirect(l); err == nil && ref.num == num {
+				obj = f.decrypt(ref, o)
+			}
+		}
+	}
+	f.cache[num] = obj
+	return obj
+}
+
+func (f *pdfFile) loadFromStream(entry xrefEntry) any {
+	stm, ok := f.streams[entry.stream]
+	if !ok {
+		stm = f.openObjectStream(entry.stream)
+		f.streams[entry.stream] = stm
+	}
+	if stm == nil || entry.index < 0 || entry.index >= len(stm.offsets) {
+		return nil
+	}
+	l := &pdfLexer{data: stm.data, pos: stm.offsets[entry.index]}
+	obj, err := l.object()
+	if err != nil {
+		return nil
+	}
+	return obj
+}
+
+func (f *pdfFile) openObjectStream(num int) *objectStream {
+	stream, ok := f.load(num).(*pdfStream)
+	if !ok {
+		return nil
+	}
+	data, err := f.decodeStream(stream)
+	if err != nil {
+		return nil
+	}
+	n, _ := stream.dict["N"].(int64)
+	first, _ := stream.dict["First"].(int64)
+	if n < 0 || first < 0 || int(first) > len(data) {
+		return nil
+	}
+	stm := &objectStream{data: data}
+	l := &pdfLexer{data: data[:first]}
+	for i := int64(0); i < n; i++ {
+		_, err1 := l.token()
+		off, err2 := l.token()
+		o, ok := off.(int64)
+		if err1 != nil || err2 != nil || !ok || int(first+o) > len(data) {
+			break
+		}
+		stm.offsets = append(stm.offsets, int(first+o))
+	}
+	return stm
+}
+
+// dict resolves v to a dictionary, taking the dictionary of a stream.
+func (f *pdfFile) dict(v any) pdfDict {
+	switch d := f.resolve(v).(type) {
+	case pdfDict:
+		return d
+	case *pdfStream:
+		return d.dict
+	}
+	return nil
+}
+
+// decodeStream applies a stream's filters to its data.
+func (f *pdfFile) decodeStream(s *pdfStream) ([]byte, error) {
+	data := s.raw
+	filters := f.resolve(s.dict["Filter"])
+	params := f.resolve(s.dict["DecodeParms"])
+	var names []pdfName
+	var parms []pdfDict
+	switch v := filters.(type) {
+	case pdfName:
+		names = []pdfName{v}
+		parms = []pdfDict{f.dict(params)}
+	case pdfArray:
+		arr, _ := params.(pdfArray)
+		for i, name := range v {
+			n, _ := f.resolve(name).(pdfName)
+			names = append(names, n)
+			var p pdfDict
+			if i < len(arr) {
+				p = f.dict(arr[i])
+			}
+			parms = append(parms, p)
+		}
+	}
+	for i, name := range names {
+		var err error
+		switch name {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+			if err == nil {
+				data, err = unpredict(data, parms[i])
+			}
+		case "ASCIIHexDecode", "AHx":
+			data = decodeASCIIHex(data)
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		default:
+			err = fmt.Errorf("unsupported PDF filter %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data. Streams truncated or corrupted near the
+// end are common, so whatever decompressed before the error is kept.
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxPDFStreamSize+1))
+	if len(out) > maxPDFStreamSize {
+		return nil, fmt.Errorf("PDF stream exceeds %d bytes", maxPDFStreamSize)
+	}
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+// unpredict reverses the PNG predictors used by FlateDecode streams, most
+// commonly xref streams.
+func unpredict(data []byte, parms pdfDict) ([]byte, error) {
+	predictor, _ := parms["Predictor"].(int64)
+	if predictor < 10 {
+		if predictor == 2 {
+			return nil, fmt.Errorf("unsupported PDF TIFF predictor")
+		}
+		return data, nil
+	}
+	colors, bpc, columns := int64(1), int64(8), int64(1)
+	if v, ok := parms["Colors"].(int64); ok {
+		colors = v
+	}
+	if v, ok := parms["BitsPerComponent"].(int64); ok {
+		bpc = v
+	}
+	if v, ok := parms["Columns"].(int64); ok {
+		columns = v
+	}
+	if colors < 1 || bpc < 1 || columns < 1 || colors*bpc*columns > 1<<20 {
+		return nil, fmt.Errorf("%w: bad predictor parameters", errInvalidPDF)
+	}
+	bpp := int(max((colors*bpc+7)/8, 1))
+	rowLen := int((colors*bpc*columns + 7) / 8)
+	out := make([]byte, 0, len(data))
+	prev := make([]byte, rowLen)
+	for i := 0; i < len(data); i += rowLen + 1 {
+		kind := data[i]
+		row := make([]byte, rowLen)
+		copy(row, data[i+1:min(i+1+rowLen, len(data))])
+		for j := range row {
+			var left, up, upLeft byte
+			if j >= bpp {
+				left = row[j-bpp]
+				upLeft = prev[j-bpp]
+			}
+			up = prev[j]
+			switch kind {
+			case 1:
+				row[j] += left
+			case 2:
+				row[j] += up
+			case 3:
+				row[j] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[j] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func decodeASCIIHex(data []byte) []byte {
+	l := &pdfLexer{data: append([]byte{'<'}, data...)}
+	return []byte(l.hexString())
+}
+
+// decodeASCII85 decodes Adobe's base-85 encoding, including the "z"
+// abbreviation and the "~>" end marker.
+func decodeASCII85(data []byte) ([]byte, error) {
+	var out []byte
+	var group [5]byte
+	n := 0
+	flush := func(count int) {
+		var v uint32
+		for i := 0; i < 5; i++ {
+			c := byte('u')
+			if i < count {
+				c = group[i]
+			}
+			v = v*85 + uint32(c-'!')
+		}
+		word := []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+		out = append(out, word[:count-1]...)
+	}
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case isPDFSpace(c):
+			continue
+		case c == '~':
+			i = len(data)
+			continue
+		case c == 'z' && n == 0:
+			out = append(out, 0, 0, 0, 0)
+			continue
+		case c < '!' || c > 'u':
+			return nil, fmt.Errorf("%w: invalid ASCII85 data", errInvalidPDF)
+		}
+		group[n] = c
+		if n++; n == 5 {
+			flush(5)
+			n = 0
+		}
+	}
+	if n == 1 {
+		return nil, fmt.Errorf("%w: truncated ASCII85 data", errInvalidPDF)
+	}
+	if n > 1 {
+		flush(n)
+	}
+	return out, nil
+}
@@ -0,0 +1,414 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+)
+
+// Crypt filter methods of the standard security handler. Identity leaves
+// data as is.
+const (
+	cryptIdentity pdfName = "Identity"
+	cryptRC4      pdfName = "V2"
+	cryptAES128   pdfName = "AESV2"
+	cryptAES256   pdfName = "AESV3"
+)
+
+// pdfPasswordPad pads passwords to 32 bytes for revisions 2 to 4.
+var pdfPasswordPad = []byte{
+	0x28, 0xbf, 0x4e, 0x5e, 0x4e, 0x75, 0x8a, 0x41, 0x64, 0x00, 0x4e, 0x56, 0xff, 0xfa, 0x01, 0x08,
+	0x2e, 0x2e, 0x00, 0xb6, 0xd0, 0x68, 0x3e, 0x80, 0x2f, 0x0c, 0xa9, 0xfe, 0x64, 0x53, 0x69, 0x7a,
+}
+
+// pdfCrypt decrypts the strings and streams of a document encrypted with
+// the standard security handler.
+type pdfCrypt struct {
+	key             []byte
+	revision        int
+	stream, str     pdfName // crypt filter methods
+	encryptMetadata bool
+	encrypt         int // object number of the encryption dictionary
+}
+
+// pdfSecurity holds the entries of an encryption dictionary that derive
+// and check the file key.
+type pdfSecurity struct {
+	revision        int
+	keyLen          int
+	o, u, oe, ue    []byte
+	p               uint32
+	id              []byte
+	encryptMetadata bool
+}
+
+// setupCrypt prepares decryption of an encrypted document, trying the
+// empty user password and then password as either the user or the owner
+// password.
+func (f *pdfFile) setupCrypt(password string) error {
+	ref, _ := f.trailer["Encrypt"].(pdfRef)
+	enc := f.dict(f.trailer["Encrypt"])
+	if enc == nil {
+		return fmt.Errorf("%w: missing encryption dictionary", errInvalidPDF)
+	}
+	if filter, _ := enc["Filter"].(pdfName); filter != "Standard" {
+		return fmt.Errorf("%w: unsupported security handler %q", ErrEncryptedDocument, filter)
+	}
+	v, _ := f.resolve(enc["V"]).(int64)
+	r, _ := f.resolve(enc["R"]).(int64)
+	sec := &pdfSecurity{revision: int(r), keyLen: 5, encryptMetadata: true}
+	if b, ok := f.resolve(enc["EncryptMetadata"]).(bool); ok {
+		sec.encryptMetadata = b
+	}
+	c := &pdfCrypt{revision: int(r), encryptMetadata: sec.encryptMetadata, encrypt: ref.num}
+	switch v {
+	case 1, 2:
+		c.stream, c.str = cryptRC4, cryptRC4
+		if length, ok := f.resolve(enc["Length"]).(int64); ok && v == 2 {
+			sec.keyLen = int(length / 8)
+		}
+	case 4, 5:
+		cf := f.dict(enc["CF"])
+		method := func(key pdfName) pdfName {
+			name, _ := f.resolve(enc[key]).(pdfName)
+			if name == "" || name == cryptIdentity {
+				return cryptIdentity
+			}
+			m, _ := f.resolve(f.dict(cf[name])["CFM"]).(pdfName)
+			if m == "None" {
+				return cryptIdentity
+			}
+			return m
+		}
+		c.stream, c.str = method("StmF"), method("StrF")
+		sec.keyLen = 16
+		if v == 5 {
+			sec.keyLen = 32
+		}
+	default:
+		return fmt.Errorf("%w: unsupported encryption version %d", ErrEncryptedDocument, v)
+	}
+	for _, m := range []pdfName{c.stream, c.str} {
+		switch {
+		case m == cryptIdentity,
+			(m == cryptRC4 || m == cryptAES128) && r >= 2 && r <= 4,
+			m == cryptAES256 && (r == 5 || r == 6):
+		default:
+			return fmt.Errorf("%w: unsupported crypt filter %s for revision %d", ErrEncryptedDocument, m, r)
+		}
+	}
+	if sec.keyLen < 5 || sec.keyLen > 16 && sec.keyLen != 32 {
+		return fmt.Errorf("%w: invalid key length", errInvalidPDF)
+	}
+
+	str := func(key pdfName) []byte {
+		s, _ := f.resolve(enc[key]).(pdfString)
+		return []byte(s)
+	}
+	sec.o, sec.u, sec.oe, sec.ue = str("O"), str("U"), str("OE"), str("UE")
+	p, _ := f.resolve(enc["P"]).(int64)
+	sec.p = uint32(p)
+	if ids, ok := f.resolve(f.trailer["ID"]).(pdfArray); ok && len(ids) > 0 {
+		id, _ := f.resolve(ids[0]).(pdfString)
+		sec.id = []byte(id)
+	}
+
+	passwords := []string{""}
+	if password != "" {
+		passwords = append(passwords, password)
+	}
+	for _, pw := range passwords {
+		if key := sec.authenticate(pw); key != nil {
+			c.key = key
+			f.crypt = c
+			// Objects loaded while reading the cross-reference data were
+			// not decrypted.
+			f.cache = make(map[int]any)
+			f.streams = make(map[int]*objectStream)
+			return nil
+		}
+	}
+	if password == "" {
+		return fmt.Errorf("%w: a password is required", ErrEncryptedDocument)
+	}
+	return fmt.Errorf("%w: incorrect password", ErrEncryptedDocument)
+}
+
+// authenticate returns the file key if password is the user or the owner
+// password, and nil otherwise.
+func (s *pdfSecurity) authenticate(password string) []byte {
+	switch s.revision {
+	case 2, 3, 4:
+		if len(s.o) < 32 || len(s.u) < 32 {
+			return nil
+		}
+		pw := latin1(password)
+		if key := s.fileKey(pw); s.checkUser(key) {
+			return key
+		}
+		if key := s.fileKey(s.ownerUserPassword(pw)); s.checkUser(key) {
+			return key
+		}
+	case 5, 6:
+		if len(s.o) < 48 || len(s.u) < 48 || len(s.oe) < 32 || len(s.ue) < 32 {
+			return nil
+		}
+		pw := []byte(password)
+		if len(pw) > 127 {
+			pw = pw[:127]
+		}
+		if bytes.Equal(s.hash(pw, s.u[32:40], nil), s.u[:32]) {
+			return aesDecryptKey(s.hash(pw, s.u[40:48], nil), s.ue[:32])
+		}
+		if bytes.Equal(s.hash(pw, s.o[32:40], s.u[:48]), s.o[:32]) {
+			return aesDecryptKey(s.hash(pw, s.o[40:48], s.u[:48]), s.oe[:32])
+		}
+	}
+	return nil
+}
+
+// latin1 encodes a password for revisions 2 to 4, which predate Unicode
+// passwords.
+func latin1(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return []byte(s)
+		}
+		b = append(b, byte(r))
+	}
+	return b
+}
+
+// padPassword truncates or pads a password to 32 bytes.
+func padPassword(pw []byte) []byte {
+	padded := make([]byte, 32)
+	n := copy(padded, pw)
+	copy(padded[n:], pdfPasswordPad)
+	return padded
+}
+
+// fileKey derives the file key from a user password (revisions 2 to 4).
+func (s *pdfSecurity) fileKey(pw []byte) []byte {
+	h := md5.New()
+	h.Write(padPassword(pw))
+	h.Write(s.o[:32])
+	binary.Write(h, binary.LittleEndian, s.p)
+	h.Write(s.id)
+	if s.revision >= 4 && !s.encryptMetadata {
+		h.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	}
+	key := h.Sum(nil)
+	if s.revision >= 3 {
+		for i := 0; i < 50; i++ {
+			sum := md5.Sum(key[:s.keyLen])
+			key = sum[:]
+		}
+	}
+	return key[:s.keyLen]
+}
+
+// checkUser reports whether key reproduces the U entry.
+func (s *pdfSecurity) checkUser(key []byte) bool {
+	if s.revision == 2 {
+		return bytes.Equal(rc4Crypt(key, pdfPasswordPad), s.u[:32])
+	}
+	h := md5.New()
+	h.Write(pdfPasswordPad)
+	h.Write(s.id)
+	data := rc4Crypt(key, h.Sum(nil))
+	for i := 1; i <= 19; i++ {
+		data = rc4Crypt(xorKey(key, byte(i)), data)
+	}
+	return bytes.Equal(data, s.u[:16])
+}
+
+// ownerKey derives the key that encrypts the user password in the O entry
+// from the owner password (revisions 2 to 4).
+func (s *pdfSecurity) ownerKey(pw []byte) []byte {
+	sum := md5.Sum(padPassword(pw))
+	key := sum[:]
+	if s.revision >= 3 {
+		for i := 0; i < 50; i++ {
+			sum = md5.Sum(key)
+			key = sum[:]
+		}
+	}
+	return key[:s.keyLen]
+}
+
+// ownerUserPassword recovers the padded user password from the O entry,
+// assuming pw is the owner password.
+func (s *pdfSecurity) ownerUserPassword(pw []byte) []byte {
+	key := s.ownerKey(pw)
+	if s.revision == 2 {
+		return rc4Crypt(key, s.o[:32])
+	}
+	data := s.o[:32]
+	for i := 19; i >= 0; i-- {
+		data = rc4Crypt(xorKey(key, byte(i)), data)
+	}
+	return data
+}
+
+// hash computes the password hash of revisions 5 and 6 over a password, a
+// salt and, for the owner password, the U entry.
+func (s *pdfSecurity) hash(pw, salt, udata []byte) []byte {
+	h := sha256.New()
+	h.Write(pw)
+	h.Write(salt)
+	h.Write(udata)
+	k := h.Sum(nil)
+	if s.revision == 5 {
+		return k
+	}
+	for i := 0; ; i++ {
+		round := make([]byte, 0, len(pw)+len(k)+len(udata))
+		round = append(append(append(round, pw...), k...), udata...)
+		k1 := bytes.Repeat(round, 64)
+		block, _ := aes.NewCipher(k[:16])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+		// The first 16 bytes of e as a big-endian number modulo 3; 256 is
+		// 1 modulo 3, so this is the sum of the bytes modulo 3.
+		sum := 0
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		var next hash.Hash
+		switch sum % 3 {
+		case 0:
+			next = sha256.New()
+		case 1:
+			next = sha512.New384()
+		default:
+			next = sha512.New()
+		}
+		next.Write(e)
+		k = next.Sum(nil)
+		if i+1 >= 64 && int(e[len(e)-1]) <= i+1-32 {
+			break
+		}
+	}
+	return k[:32]
+}
+
+// aesDecryptKey decrypts a UE or OE entry, which is AES-256 encrypted
+// without padding and with a zero IV.
+func aesDecryptKey(key, data []byte) []byte {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, data)
+	return out
+}
+
+func rc4Crypt(key, data []byte) []byte {
+	c, err := rc4.NewCipher(key)
+	if err != nil {
+		return nil
+	}
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+func xorKey(key []byte, x byte) []byte {
+	out := make([]byte, len(key))
+	for i, b := range key {
+		out[i] = b ^ x
+	}
+	return out
+}
+
+// decrypt returns obj, read as the indirect object ref, with its strings
+// and stream data decrypted. Objects inside object streams are decrypted
+// with the stream, and cross-reference streams and the encryption
+// dictionary are never encrypted.
+func (f *pdfFile) decrypt(ref pdfRef, obj any) any {
+	c := f.crypt
+	if c == nil || ref.num == c.encrypt {
+		return obj
+	}
+	var walk func(v any) any
+	walk = func(v any) any {
+		switch v := v.(type) {
+		case pdfString:
+			return pdfString(c.decryptData(c.str, ref, []byte(v)))
+		case pdfArray:
+			out := make(pdfArray, len(v))
+			for i, e := range v {
+				out[i] = walk(e)
+			}
+			return out
+		case pdfDict:
+			out := make(pdfDict, len(v))
+			for k, e := range v {
+				out[k] = walk(e)
+			}
+			return out
+		case *pdfStream:
+			if v.dict["Type"] == pdfName("XRef") {
+				return v
+			}
+			s := &pdfStream{dict: walk(v.dict).(pdfDict), raw: v.raw}
+			if v.dict["Type"] != pdfName("Metadata") || c.encryptMetadata {
+				s.raw = c.decryptData(c.stream, ref, v.raw)
+			}
+			return s
+		}
+		return v
+	}
+	return walk(obj)
+}
+
+// decryptData decrypts the data of a string or stream of object ref.
+func (c *pdfCrypt) decryptData(method pdfName, ref pdfRef, data []byte) []byte {
+	switch method {
+	case cryptRC4:
+		return rc4Crypt(c.objectKey(ref, false), data)
+	case cryptAES128:
+		return aesDecrypt(c.objectKey(ref, true), data)
+	case cryptAES256:
+		return aesDecrypt(c.key, data)
+	}
+	return data
+}
+
+// objectKey derives the key of an object from the file key (revisions 2
+// to 4).
+func (c *pdfCrypt) objectKey(ref pdfRef, aes bool) []byte {
+	h := md5.New()
+	h.Write(c.key)
+	h.Write([]byte{byte(ref.num), byte(ref.num >> 8), byte(ref.num >> 16), byte(ref.gen), byte(ref.gen >> 8)})
+	if aes {
+		h.Write([]byte("sAlT"))
+	}
+	return h.Sum(nil)[:min(len(c.key)+5, 16)]
+}
+
+// aesDecrypt decrypts AES-CBC data that starts with its IV and ends with
+// PKCS#5 padding. Truncated data is decrypted as far as it goes.
+func aesDecrypt(key, data []byte) []byte {
+	block, err := aes.NewCipher(key)
+	if err != nil || len(data) < 2*aes.BlockSize {
+		return nil
+	}
+	iv, body := data[:aes.BlockSize], data[aes.BlockSize:]
+	body = body[:len(body)/aes.BlockSize*aes.BlockSize]
+	out := make([]byte, len(body))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, body)
+	if pad := int(out[len(out)-1]); pad >= 1 && pad <= aes.BlockSize && bytes.Equal(out[len(out)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		out = out[:len(out)-pad]
+	}
+	return out
+}
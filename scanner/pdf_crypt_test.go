@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// encryptedPDF builds a one-page PDF encrypted with the standard security
+// handler at revision rev (3, 4 or 6), with the given user and owner
+// passwords. Object 6 holds the encrypted string title.
+func encryptedPDF(t *testing.T, rev int, user, owner, text, title string) []byte {
+	t.Helper()
+	id := []byte("0123456789abcdef")
+	sec := &pdfSecurity{revision: rev, keyLen: 16, p: 0xffffc4c4 /* P -15164 */, id: id, encryptMetadata: true}
+	c := &pdfCrypt{revision: rev, encryptMetadata: true, encrypt: 7}
+	var encDict string
+	switch rev {
+	case 3, 4:
+		// O holds the padded user password encrypted with the owner key.
+		ownerKey := sec.ownerKey([]byte(owner))
+		sec.o = padPassword([]byte(user))
+		for i := 0; i <= 19; i++ {
+			sec.o = rc4Crypt(xorKey(ownerKey, byte(i)), sec.o)
+		}
+		c.key = sec.fileKey([]byte(user))
+		sum := md5.Sum(append(append([]byte{}, pdfPasswordPad...), id...))
+		u := rc4Crypt(c.key, sum[:])
+		for i := 1; i <= 19; i++ {
+			u = rc4Crypt(xorKey(c.key, byte(i)), u)
+		}
+		sec.u = append(u, make([]byte, 16)...)
+		c.stream, c.str = cryptRC4, cryptRC4
+		encDict = fmt.Sprintf("<< /Filter /Standard /V 2 /R 3 /Length 128 /O <%x> /U <%x> /P -15164 >>", sec.o, sec.u)
+		if rev == 4 {
+			c.stream, c.str = cryptAES128, cryptAES128
+			encDict = fmt.Sprintf("<< /Filter /Standard /V 4 /R 4 /Length 128 /CF << /StdCF << /CFM /AESV2 /Length 16 >> >> /StmF /StdCF /StrF /StdCF /O <%x> /U <%x> /P -15164 >>", sec.o, sec.u)
+		}
+	case 6:
+		c.key = bytes.Repeat([]byte{0x5a}, 32)
+		c.stream, c.str = cryptAES256, cryptAES256
+		userSalts, ownerSalts := []byte("uvalsaltukeysalt"), []byte("ovalsaltokeysalt")
+		sec.u = append(sec.hash([]byte(user), userSalts[:8], nil), userSalts...)
+		sec.ue = aesEncryptKey(sec.hash([]byte(user), userSalts[8:], nil), c.key)
+		sec.o = append(sec.hash([]byte(owner), ownerSalts[:8], sec.u), ownerSalts...)
+		sec.oe = aesEncryptKey(sec.hash([]byte(owner), ownerSalts[8:], sec.u), c.key)
+		encDict = fmt.Sprintf("<< /Filter /Standard /V 5 /R 6 /Length 256 /CF << /StdCF << /CFM /AESV3 /Length 32 >> >> /StmF /StdCF /StrF /StdCF /O <%x> /U <%x> /OE <%x> /UE <%x> /P -15164 >>", sec.o, sec.u, sec.oe, sec.ue)
+	default:
+		t.Fatalf("unsupported revision %d", rev)
+	}
+
+	encrypt := func(num int, method pdfName, data string) []byte {
+		ref := pdfRef{num: num}
+		switch method {
+		case cryptRC4:
+			return rc4Crypt(c.objectKey(ref, false), []byte(data))
+		case cryptAES128:
+			return aesEncrypt(c.objectKey(ref, true), []byte(data))
+		}
+		return aesEncrypt(c.key, []byte(data))
+	}
+	content := string(encrypt(4, c.stream, "BT /F1 12 Tf 72 720 Td ("+text+") Tj ET"))
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		streamObject("", content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		fmt.Sprintf("<< /Title <%x> >>", encrypt(6, c.str, title)),
+		encDict,
+	})
+	return bytes.Replace(data, []byte("/Root 1 0 R"), []byte(fmt.Sprintf("/Root 1 0 R /Encrypt 7 0 R /ID [<%x> <%x>]", id, id)), 1)
+}
+
+// aesEncrypt encrypts data with AES-CBC as a PDF stream or string, with a
+// leading IV and PKCS#5 padding.
+func aesEncrypt(key, data []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	data = append(data, bytes.Repeat([]byte{byte(pad)}, pad)...)
+	out := make([]byte, aes.BlockSize+len(data))
+	copy(out, "initialvector123")
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(out[aes.BlockSize:], data)
+	return out
+}
+
+// aesEncryptKey encrypts a file key for a UE or OE entry.
+func aesEncryptKey(key, data []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, data)
+	return out
+}
+
+func TestExtractEncryptedPDF(t *testing.T) {
+	tests := []struct {
+		name        string
+		rev         int
+		user, owner string
+	}{
+		{"rc4 empty user password", 3, "", "owner"},
+		{"rc4", 3, "s3cret", "owner"},
+		{"aes-128", 4, "s3cret", "owner"},
+		{"aes-256", 6, "pässwörd", "owner"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := encryptedPDF(t, tt.rev, tt.user, tt.owner, "TOP SECRET", "Quarterly report")
+			for _, pw := range []string{tt.user, tt.owner} {
+				text, err := ExtractTextWith(data, "doc.pdf", ExtractOptions{Password: pw})
+				if err != nil || text != "TOP SECRET" {
+					t.Errorf("password %q: expected decrypted text, got %q, %v", pw, text, err)
+				}
+			}
+			if tt.user == "" {
+				return
+			}
+			if _, err := ExtractText(data, "doc.pdf"); !errors.Is(err, ErrEncryptedDocument) || !strings.Contains(err.Error(), "password is required") {
+				t.Errorf("Expected a password to be required, got %v", err)
+			}
+			_, err := ExtractTextWith(data, "doc.pdf", ExtractOptions{Password: "guess"})
+			if !errors.Is(err, ErrEncryptedDocument) || !strings.Contains(err.Error(), "incorrect password") {
+				t.Errorf("Expected an incorrect password error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPDFDecryptsStrings(t *testing.T) {
+	data := encryptedPDF(t, 4, "", "owner", "text", "Quarterly report")
+	f, err := openPDF(data)
+	if err != nil {
+		t.Fatalf("openPDF failed: %v", err)
+	}
+	if err := f.setupCrypt(""); err != nil {
+		t.Fatalf("setupCrypt failed: %v", err)
+	}
+	if title := f.dict(pdfRef{num: 6})["Title"]; title != pdfString("Quarterly report") {
+		t.Errorf("Expected decrypted title, got %q", title)
+	}
+	if _, ok := f.dict(pdfRef{num: 7})["O"].(pdfString); !ok {
+		t.Error("Expected the encryption dictionary to be left as is")
+	}
+}
+
+func TestExtractEncryptedPDFUnsupported(t *testing.T) {
+	data := buildPDF([]string{"<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>"})
+	data = bytes.Replace(data, []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt << /Filter /Adobe.PubSec /V 4 >>"), 1)
+	_, err := ExtractTextWith(data, "doc.pdf", ExtractOptions{Password: "secret"})
+	if !errors.Is(err, ErrEncryptedDocument) || !strings.Contains(err.Error(), "Adobe.PubSec") {
+		t.Errorf("Expected an unsupported security handler error, got %v", err)
+	}
+}
@@ -0,0 +1,391 @@
+package scanner
+
+// Character encodings of simple PDF fonts, mapping single-byte codes to
+// Unicode. Zero marks codes the encoding leaves undefined.
+
+// winAnsiEncoding is Windows code page 1252.
+var winAnsiEncoding = [256]rune{
+	0x20: 0x0020, 0x21: 0x0021, 0x22: 0x0022, 0x23: 0x0023, 0x24: 0x0024, 0x25: 0x0025, 0x26: 0x0026, 0x27: 0x0027,
+	0x28: 0x0028, 0x29: 0x0029, 0x2a: 0x002a, 0x2b: 0x002b, 0x2c: 0x002c, 0x2d: 0x002d, 0x2e: 0x002e, 0x2f: 0x002f,
+	0x30: 0x0030, 0x31: 0x0031, 0x32: 0x0032, 0x33: 0x0033, 0x34: 0x0034, 0x35: 0x0035, 0x36: 0x0036, 0x37: 0x0037,
+	0x38: 0x0038, 0x39: 0x0039, 0x3a: 0x003a, 0x3b: 0x003b, 0x3c: 0x003c, 0x3d: 0x003d, 0x3e: 0x003e, 0x3f: 0x003f,
+	0x40: 0x0040, 0x41: 0x0041, 0x42: 0x0042, 0x43: 0x0043, 0x44: 0x0044, 0x45: 0x0045, 0x46: 0x0046, 0x47: 0x0047,
+	0x48: 0x0048, 0x49: 0x0049, 0x4a: 0x004a, 0x4b: 0x004b, 0x4c: 0x004c, 0x4d: 0x004d, 0x4e: 0x004e, 0x4f: 0x004f,
+	0x50: 0x0050, 0x51: 0x0051, 0x52: 0x0052, 0x53: 0x0053, 0x54: 0x0054, 0x55: 0x0055, 0x56: 0x0056, 0x57: 0x0057,
+	0x58: 0x0058, 0x59: 0x0059, 0x5a: 0x005a, 0x5b: 0x005b, 0x5c: 0x005c, 0x5d: 0x005d, 0x5e: 0x005e, 0x5f: 0x005f,
+	0x60: 0x0060, 0x61: 0x0061, 0x62: 0x0062, 0x63: 0x0063, 0x64: 0x0064, 0x65: 0x0065, 0x66: 0x0066, 0x67: 0x0067,
+	0x68: 0x0068, 0x69: 0x0069, 0x6a: 0x006a, 0x6b: 0x006b, 0x6c: 0x006c, 0x6d: 0x006d, 0x6e: 0x006e, 0x6f: 0x006f,
+	0x70: 0x0070, 0x71: 0x0071, 0x72: 0x0072, 0x73: 0x0073, 0x74: 0x0074, 0x75: 0x0075, 0x76: 0x0076, 0x77: 0x0077,
+	0x78: 0x0078, 0x79: 0x0079, 0x7a: 0x007a, 0x7b: 0x007b, 0x7c: 0x007c, 0x7d: 0x007d, 0x7e: 0x007e,
+	0x80: 0x20ac, 0x82: 0x201a, 0x83: 0x0192, 0x84: 0x201e, 0x85: 0x2026, 0x86: 0x2020, 0x87: 0x2021,
+	0x88: 0x02c6, 0x89: 0x2030, 0x8a: 0x0160, 0x8b: 0x2039, 0x8c: 0x0152, 0x8e: 0x017d,
+	0x91: 0x2018, 0x92: 0x2019, 0x93: 0x201c, 0x94: 0x201d, 0x95: 0x2022, 0x96: 0x2013, 0x97: 0x2014,
+	0x98: 0x02dc, 0x99: 0x2122, 0x9a: 0x0161, 0x9b: 0x203a, 0x9c: 0x0153, 0x9e: 0x017e, 0x9f: 0x0178,
+	0xa0: 0x00a0, 0xa1: 0x00a1, 0xa2: 0x00a2, 0xa3: 0x00a3, 0xa4: 0x00a4, 0xa5: 0x00a5, 0xa6: 0x00a6, 0xa7: 0x00a7,
+	0xa8: 0x00a8, 0xa9: 0x00a9, 0xaa: 0x00aa, 0xab: 0x00ab, 0xac: 0x00ac, 0xad: 0x00ad, 0xae: 0x00ae, 0xaf: 0x00af,
+	0xb0: 0x00b0, 0xb1: 0x00b1, 0xb2: 0x00b2, 0xb3: 0x00b3, 0xb4: 0x00b4, 0xb5: 0x00b5, 0xb6: 0x00b6, 0xb7: 0x00b7,
+	0xb8: 0x00b8, 0xb9: 0x00b9, 0xba: 0x00ba, 0xbb: 0x00bb, 0xbc: 0x00bc, 0xbd: 0x00bd, 0xbe: 0x00be, 0xbf: 0x00bf,
+	0xc0: 0x00c0, 0xc1: 0x00c1, 0xc2: 0x00c2, 0xc3: 0x00c3, 0xc4: 0x00c4, 0xc5: 0x00c5, 0xc6: 0x00c6, 0xc7: 0x00c7,
+	0xc8: 0x00c8, 0xc9: 0x00c9, 0xca: 0x00ca, 0xcb: 0x00cb, 0xcc: 0x00cc, 0xcd: 0x00cd, 0xce: 0x00ce, 0xcf: 0x00cf,
+	0xd0: 0x00d0, 0xd1: 0x00d1, 0xd2: 0x00d2, 0xd3: 0x00d3, 0xd4: 0x00d4, 0xd5: 0x00d5, 0xd6: 0x00d6, 0xd7: 0x00d7,
+	0xd8: 0x00d8, 0xd9: 0x00d9, 0xda: 0x00da, 0xdb: 0x00db, 0xdc: 0x00dc, 0xdd: 0x00dd, 0xde: 0x00de, 0xdf: 0x00df,
+	0xe0: 0x00e0, 0xe1: 0x00e1, 0xe2: 0x00e2, 0xe3: 0x00e3, 0xe4: 0x00e4, 0xe5: 0x00e5, 0xe6: 0x00e6, 0xe7: 0x00e7,
+	0xe8: 0x00e8, 0xe9: 0x00e9, 0xea: 0x00ea, 0xeb: 0x00eb, 0xec: 0x00ec, 0xed: 0x00ed, 0xee: 0x00ee, 0xef: 0x00ef,
+	0xf0: 0x00f0, 0xf1: 0x00f1, 0xf2: 0x00f2, 0xf3: 0x00f3, 0xf4: 0x00f4, 0xf5: 0x00f5, 0xf6: 0x00f6, 0xf7: 0x00f7,
+	0xf8: 0x00f8, 0xf9: 0x00f9, 0xfa: 0x00fa, 0xfb: 0x00fb, 0xfc: 0x00fc, 0xfd: 0x00fd, 0xfe: 0x00fe, 0xff: 0x00ff,
+}
+
+// macRomanEncoding is the Mac OS Roman character set.
+var macRomanEncoding = [256]rune{
+	0x20: 0x0020, 0x21: 0x0021, 0x22: 0x0022, 0x23: 0x0023, 0x24: 0x0024, 0x25: 0x0025, 0x26: 0x0026, 0x27: 0x0027,
+	0x28: 0x0028, 0x29: 0x0029, 0x2a: 0x002a, 0x2b: 0x002b, 0x2c: 0x002c, 0x2d: 0x002d, 0x2e: 0x002e, 0x2f: 0x002f,
+	0x30: 0x0030, 0x31: 0x0031, 0x32: 0x0032, 0x33: 0x0033, 0x34: 0x0034, 0x35: 0x0035, 0x36: 0x0036, 0x37: 0x0037,
+	0x38: 0x0038, 0x39: 0x0039, 0x3a: 0x003a, 0x3b: 0x003b, 0x3c: 0x003c, 0x3d: 0x003d, 0x3e: 0x003e, 0x3f: 0x003f,
+	0x40: 0x0040, 0x41: 0x0041, 0x42: 0x0042, 0x43: 0x0043, 0x44: 0x0044, 0x45: 0x0045, 0x46: 0x0046, 0x47: 0x0047,
+	0x48: 0x0048, 0x49: 0x0049, 0x4a: 0x004a, 0x4b: 0x004b, 0x4c: 0x004c, 0x4d: 0x004d, 0x4e: 0x004e, 0x4f: 0x004f,
+	0x50: 0x0050, 0x51: 0x0051, 0x52: 0x0052, 0x53: 0x0053, 0x54: 0x0054, 0x55: 0x0055, 0x56: 0x0056, 0x57: 0x0057,
+	0x58: 0x0058, 0x59: 0x0059, 0x5a: 0x005a, 0x5b: 0x005b, 0x5c: 0x005c, 0x5d: 0x005d, 0x5e: 0x005e, 0x5f: 0x005f,
+	0x60: 0x0060, 0x61: 0x0061, 0x62: 0x0062, 0x63: 0x0063, 0x64: 0x0064, 0x65: 0x0065, 0x66: 0x0066, 0x67: 0x0067,
+	0x68: 0x0068, 0x69: 0x0069, 0x6a: 0x006a, 0x6b: 0x006b, 0x6c: 0x006c, 0x6d: 0x006d, 0x6e: 0x006e, 0x6f: 0x006f,
+	0x70: 0x0070, 0x71: 0x0071, 0x72: 0x0072, 0x73: 0x0073, 0x74: 0x0074, 0x75: 0x0075, 0x76: 0x0076, 0x77: 0x0077,
+	0x78: 0x0078, 0x79: 0x0079, 0x7a: 0x007a, 0x7b: 0x007b, 0x7c: 0x007c, 0x7d: 0x007d, 0x7e: 0x007e,
+	0x80: 0x00c4, 0x81: 0x00c5, 0x82: 0x00c7, 0x83: 0x00c9, 0x84: 0x00d1, 0x85: 0x00d6, 0x86: 0x00dc, 0x87: 0x00e1,
+	0x88: 0x00e0, 0x89: 0x00e2, 0x8a: 0x00e4, 0x8b: 0x00e3, 0x8c: 0x00e5, 0x8d: 0x00e7, 0x8e: 0x00e9, 0x8f: 0x00e8,
+	0x90: 0x00ea, 0x91: 0x00eb, 0x92: 0x00ed, 0x93: 0x00ec, 0x94: 0x00ee, 0x95: 0x00ef, 0x96: 0x00f1, 0x97: 0x00f3,
+	0x98: 0x00f2, 0x99: 0x00f4, 0x9a: 0x00f6, 0x9b: 0x00f5, 0x9c: 0x00fa, 0x9d: 0x00f9, 0x9e: 0x00fb, 0x9f: 0x00fc,
+	0xa0: 0x2020, 0xa1: 0x00b0, 0xa2: 0x00a2, 0xa3: 0x00a3, 0xa4: 0x00a7, 0xa5: 0x2022, 0xa6: 0x00b6, 0xa7: 0x00df,
+	0xa8: 0x00ae, 0xa9: 0x00a9, 0xaa: 0x2122, 0xab: 0x00b4, 0xac: 0x00a8, 0xad: 0x2260, 0xae: 0x00c6, 0xaf: 0x00d8,
+	0xb0: 0x221e, 0xb1: 0x00b1, 0xb2: 0x2264, 0xb3: 0x2265, 0xb4: 0x00a5, 0xb5: 0x00b5, 0xb6: 0x2202, 0xb7: 0x2211,
+	0xb8: 0x220f, 0xb9: 0x03c0, 0xba: 0x222b, 0xbb: 0x00aa, 0xbc: 0x00ba, 0xbd: 0x03a9, 0xbe: 0x00e6, 0xbf: 0x00f8,
+	0xc0: 0x00bf, 0xc1: 0x00a1, 0xc2: 0x00ac, 0xc3: 0x221a, 0xc4: 0x0192, 0xc5: 0x2248, 0xc6: 0x2206, 0xc7: 0x00ab,
+	0xc8: 0x00bb, 0xc9: 0x2026, 0xca: 0x00a0, 0xcb: 0x00c0, 0xcc: 0x00c3, 0xcd: 0x00d5, 0xce: 0x0152, 0xcf: 0x0153,
+	0xd0: 0x2013, 0xd1: 0x2014, 0xd2: 0x201c, 0xd3: 0x201d, 0xd4: 0x2018, 0xd5: 0x2019, 0xd6: 0x00f7, 0xd7: 0x25ca,
+	0xd8: 0x00ff, 0xd9: 0x0178, 0xda: 0x2044, 0xdb: 0x20ac, 0xdc: 0x2039, 0xdd: 0x203a, 0xde: 0xfb01, 0xdf: 0xfb02,
+	0xe0: 0x2021, 0xe1: 0x00b7, 0xe2: 0x201a, 0xe3: 0x201e, 0xe4: 0x2030, 0xe5: 0x00c2, 0xe6: 0x00ca, 0xe7: 0x00c1,
+	0xe8: 0x00cb, 0xe9: 0x00c8, 0xea: 0x00cd, 0xeb: 0x00ce, 0xec: 0x00cf, 0xed: 0x00cc, 0xee: 0x00d3, 0xef: 0x00d4,
+	0xf0: 0xf8ff, 0xf1: 0x00d2, 0xf2: 0x00da, 0xf3: 0x00db, 0xf4: 0x00d9, 0xf5: 0x0131, 0xf6: 0x02c6, 0xf7: 0x02dc,
+	0xf8: 0x00af, 0xf9: 0x02d8, 0xfa: 0x02d9, 0xfb: 0x02da, 0xfc: 0x00b8, 0xfd: 0x02dd, 0xfe: 0x02db, 0xff: 0x02c7,
+}
+
+// standardEncoding is Adobe StandardEncoding, the default for Type 1 fonts.
+var standardEncoding = [256]rune{
+	0x20: 0x0020, 0x21: 0x0021, 0x22: 0x0022, 0x23: 0x0023, 0x24: 0x0024, 0x25: 0x0025, 0x26: 0x0026, 0x27: 0x2019,
+	0x28: 0x0028, 0x29: 0x0029, 0x2a: 0x002a, 0x2b: 0x002b, 0x2c: 0x002c, 0x2d: 0x002d, 0x2e: 0x002e, 0x2f: 0x002f,
+	0x30: 0x0030, 0x31: 0x0031, 0x32: 0x0032, 0x33: 0x0033, 0x34: 0x0034, 0x35: 0x0035, 0x36: 0x0036, 0x37: 0x0037,
+	0x38: 0x0038, 0x39: 0x0039, 0x3a: 0x003a, 0x3b: 0x003b, 0x3c: 0x003c, 0x3d: 0x003d, 0x3e: 0x003e, 0x3f: 0x003f,
+	0x40: 0x0040, 0x41: 0x0041, 0x42: 0x0042, 0x43: 0x0043, 0x44: 0x0044, 0x45: 0x0045, 0x46: 0x0046, 0x47: 0x0047,
+	0x48: 0x0048, 0x49: 0x0049, 0x4a: 0x004a, 0x4b: 0x004b, 0x4c: 0x004c, 0x4d: 0x004d, 0x4e: 0x004e, 0x4f: 0x004f,
+	0x50: 0x0050, 0x51: 0x0051, 0x52: 0x0052, 0x53: 0x0053, 0x54: 0x0054, 0x55: 0x0055, 0x56: 0x0056, 0x57: 0x0057,
+	0x58: 0x0058, 0x59: 0x0059, 0x5a: 0x005a, 0x5b: 0x005b, 0x5c: 0x005c, 0x5d: 0x005d, 0x5e: 0x005e, 0x5f: 0x005f,
+	0x60: 0x2018, 0x61: 0x0061, 0x62: 0x0062, 0x63: 0x0063, 0x64: 0x0064, 0x65: 0x0065, 0x66: 0x0066, 0x67: 0x0067,
+	0x68: 0x0068, 0x69: 0x0069, 0x6a: 0x006a, 0x6b: 0x006b, 0x6c: 0x006c, 0x6d: 0x006d, 0x6e: 0x006e, 0x6f: 0x006f,
+	0x70: 0x0070, 0x71: 0x0071, 0x72: 0x0072, 0x73: 0x0073, 0x74: 0x0074, 0x75: 0x0075, 0x76: 0x0076, 0x77: 0x0077,
+	0x78: 0x0078, 0x79: 0x0079, 0x7a: 0x007a, 0x7b: 0x007b, 0x7c: 0x007c, 0x7d: 0x007d, 0x7e: 0x007e,
+	0xa1: 0x00a1, 0xa2: 0x00a2, 0xa3: 0x00a3, 0xa4: 0x2044, 0xa5: 0x00a5, 0xa6: 0x0192, 0xa7: 0x00a7,
+	0xa8: 0x00a4, 0xa9: 0x0027, 0xaa: 0x201c, 0xab: 0x00ab, 0xac: 0x2039, 0xad: 0x203a, 0xae: 0xfb01, 0xaf: 0xfb02,
+	0xb1: 0x2013, 0xb2: 0x2020, 0xb3: 0x2021, 0xb4: 0x00b7, 0xb6: 0x00b6, 0xb7: 0x2022,
+	0xb8: 0x201a, 0xb9: 0x201e, 0xba: 0x201d, 0xbb: 0x00bb, 0xbc: 0x2026, 0xbd: 0x2030, 0xbf: 0x00bf,
+	0xc1: 0x0060, 0xc2: 0x00b4, 0xc3: 0x02c6, 0xc4: 0x02dc, 0xc5: 0x00af, 0xc6: 0x02d8, 0xc7: 0x02d9,
+	0xc8: 0x00a8, 0xca: 0x02da, 0xcb: 0x00b8, 0xcd: 0x02dd, 0xce: 0x02db, 0xcf: 0x02c7,
+	0xd0: 0x2014,
+	0xe1: 0x00c6, 0xe3: 0x00aa,
+	0xe8: 0x0141, 0xe9: 0x00d8, 0xea: 0x0152, 0xeb: 0x00ba,
+	0xf1: 0x00e6, 0xf5: 0x0131,
+	0xf8: 0x0142, 0xf9: 0x00f8, 0xfa: 0x0153, 0xfb: 0x00df,
+}
+
+// glyphNames maps the Adobe glyph names used in /Differences arrays to
+// Unicode. Names of the form uniXXXX and uXXXX are decoded by glyphRune.
+var glyphNames = map[string]rune{
+	"space":          0x0020,
+	"exclam":         0x0021,
+	"quotedbl":       0x0022,
+	"numbersign":     0x0023,
+	"dollar":         0x0024,
+	"percent":        0x0025,
+	"ampersand":      0x0026,
+	"quotesingle":    0x0027,
+	"parenleft":      0x0028,
+	"parenright":     0x0029,
+	"asterisk":       0x002a,
+	"plus":           0x002b,
+	"comma":          0x002c,
+	"hyphen":         0x002d,
+	"period":         0x002e,
+	"slash":          0x002f,
+	"zero":           0x0030,
+	"one":            0x0031,
+	"two":            0x0032,
+	"three":          0x0033,
+	"four":           0x0034,
+	"five":           0x0035,
+	"six":            0x0036,
+	"seven":          0x0037,
+	"eight":          0x0038,
+	"nine":           0x0039,
+	"colon":          0x003a,
+	"semicolon":      0x003b,
+	"less":           0x003c,
+	"equal":          0x003d,
+	"greater":        0x003e,
+	"question":       0x003f,
+	"at":             0x0040,
+	"A":              0x0041,
+	"B":              0x0042,
+	"C":              0x0043,
+	"D":              0x0044,
+	"E":              0x0045,
+	"F":              0x0046,
+	"G":              0x0047,
+	"H":              0x0048,
+	"I":              0x0049,
+	"J":              0x004a,
+	"K":              0x004b,
+	"L":              0x004c,
+	"M":              0x004d,
+	"N":              0x004e,
+	"O":              0x004f,
+	"P":              0x0050,
+	"Q":              0x0051,
+	"R":              0x0052,
+	"S":              0x0053,
+	"T":              0x0054,
+	"U":              0x0055,
+	"V":              0x0056,
+	"W":              0x0057,
+	"X":              0x0058,
+	"Y":              0x0059,
+	"Z":              0x005a,
+	"bracketleft":    0x005b,
+	"backslash":      0x005c,
+	"bracketright":   0x005d,
+	"asciicircum":    0x005e,
+	"underscore":     0x005f,
+	"grave":          0x0060,
+	"a":              0x0061,
+	"b":              0x0062,
+	"c":              0x0063,
+	"d":              0x0064,
+	"e":              0x0065,
+	"f":              0x0066,
+	"g":              0x0067,
+	"h":              0x0068,
+	"i":              0x0069,
+	"j":              0x006a,
+	"k":              0x006b,
+	"l":              0x006c,
+	"m":              0x006d,
+	"n":              0x006e,
+	"o":              0x006f,
+	"p":              0x0070,
+	"q":              0x0071,
+	"r":              0x0072,
+	"s":              0x0073,
+	"t":              0x0074,
+	"u":              0x0075,
+	"v":              0x0076,
+	"w":              0x0077,
+	"x":              0x0078,
+	"y":              0x0079,
+	"z":              0x007a,
+	"braceleft":      0x007b,
+	"bar":            0x007c,
+	"braceright":     0x007d,
+	"asciitilde":     0x007e,
+	"nbspace":        0x00a0,
+	"exclamdown":     0x00a1,
+	"cent":           0x00a2,
+	"sterling":       0x00a3,
+	"currency":       0x00a4,
+	"yen":            0x00a5,
+	"brokenbar":      0x00a6,
+	"section":        0x00a7,
+	"dieresis":       0x00a8,
+	"copyright":      0x00a9,
+	"ordfeminine":    0x00aa,
+	"guillemotleft":  0x00ab,
+	"logicalnot":     0x00ac,
+	"sfthyphen":      0x00ad,
+	"registered":     0x00ae,
+	"macron":         0x00af,
+	"degree":         0x00b0,
+	"plusminus":      0x00b1,
+	"twosuperior":    0x00b2,
+	"threesuperior":  0x00b3,
+	"acute":          0x00b4,
+	"mu":             0x00b5,
+	"paragraph":      0x00b6,
+	"periodcentered": 0x00b7,
+	"cedilla":        0x00b8,
+	"onesuperior":    0x00b9,
+	"ordmasculine":   0x00ba,
+	"guillemotright": 0x00bb,
+	"onequarter":     0x00bc,
+	"onehalf":        0x00bd,
+	"threequarters":  0x00be,
+	"questiondown":   0x00bf,
+	"Agrave":         0x00c0,
+	"Aacute":         0x00c1,
+	"Acircumflex":    0x00c2,
+	"Atilde":         0x00c3,
+	"Adieresis":      0x00c4,
+	"Aring":          0x00c5,
+	"AE":             0x00c6,
+	"Ccedilla":       0x00c7,
+	"Egrave":         0x00c8,
+	"Eacute":         0x00c9,
+	"Ecircumflex":    0x00ca,
+	"Edieresis":      0x00cb,
+	"Igrave":         0x00cc,
+	"Iacute":         0x00cd,
+	"Icircumflex":    0x00ce,
+	"Idieresis":      0x00cf,
+	"Eth":            0x00d0,
+	"Ntilde":         0x00d1,
+	"Ograve":         0x00d2,
+	"Oacute":         0x00d3,
+	"Ocircumflex":    0x00d4,
+	"Otilde":         0x00d5,
+	"Odieresis":      0x00d6,
+	"multiply":       0x00d7,
+	"Oslash":         0x00d8,
+	"Ugrave":         0x00d9,
+	"Uacute":         0x00da,
+	"Ucircumflex":    0x00db,
+	"Udieresis":      0x00dc,
+	"Yacute":         0x00dd,
+	"Thorn":          0x00de,
+	"germandbls":     0x00df,
+	"agrave":         0x00e0,
+	"aacute":         0x00e1,
+	"acircumflex":    0x00e2,
+	"atilde":         0x00e3,
+	"adieresis":      0x00e4,
+	"aring":          0x00e5,
+	"ae":             0x00e6,
+	"ccedilla":       0x00e7,
+	"egrave":         0x00e8,
+	"eacute":         0x00e9,
+	"ecircumflex":    0x00ea,
+	"edieresis":      0x00eb,
+	"igrave":         0x00ec,
+	"iacute":         0x00ed,
+	"icircumflex":    0x00ee,
+	"idieresis":      0x00ef,
+	"eth":            0x00f0,
+	"ntilde":         0x00f1,
+	"ograve":         0x00f2,
+	"oacute":         0x00f3,
+	"ocircumflex":    0x00f4,
+	"otilde":         0x00f5,
+	"odieresis":      0x00f6,
+	"divide":         0x00f7,
+	"oslash":         0x00f8,
+	"ugrave":         0x00f9,
+	"uacute":         0x00fa,
+	"ucircumflex":    0x00fb,
+	"udieresis":      0x00fc,
+	"yacute":         0x00fd,
+	"thorn":          0x00fe,
+	"ydieresis":      0x00ff,
+	"Cacute":         0x0106,
+	"cacute":         0x0107,
+	"Ccircumflex":    0x0108,
+	"ccircumflex":    0x0109,
+	"Ccaron":         0x010c,
+	"ccaron":         0x010d,
+	"Dcaron":         0x010e,
+	"dcaron":         0x010f,
+	"Ecaron":         0x011a,
+	"ecaron":         0x011b,
+	"Gcircumflex":    0x011c,
+	"gcircumflex":    0x011d,
+	"Gcedilla":       0x0122,
+	"gcedilla":       0x0123,
+	"Hcircumflex":    0x0124,
+	"hcircumflex":    0x0125,
+	"Itilde":         0x0128,
+	"itilde":         0x0129,
+	"dotlessi":       0x0131,
+	"Jcircumflex":    0x0134,
+	"jcircumflex":    0x0135,
+	"Kcedilla":       0x0136,
+	"kcedilla":       0x0137,
+	"Lacute":         0x0139,
+	"lacute":         0x013a,
+	"Lcedilla":       0x013b,
+	"lcedilla":       0x013c,
+	"Lcaron":         0x013d,
+	"lcaron":         0x013e,
+	"Lslash":         0x0141,
+	"lslash":         0x0142,
+	"Nacute":         0x0143,
+	"nacute":         0x0144,
+	"Ncedilla":       0x0145,
+	"ncedilla":       0x0146,
+	"Ncaron":         0x0147,
+	"ncaron":         0x0148,
+	"OE":             0x0152,
+	"oe":             0x0153,
+	"Racute":         0x0154,
+	"racute":         0x0155,
+	"Rcedilla":       0x0156,
+	"rcedilla":       0x0157,
+	"Rcaron":         0x0158,
+	"rcaron":         0x0159,
+	"Sacute":         0x015a,
+	"sacute":         0x015b,
+	"Scircumflex":    0x015c,
+	"scircumflex":    0x015d,
+	"Scedilla":       0x015e,
+	"scedilla":       0x015f,
+	"Scaron":         0x0160,
+	"scaron":         0x0161,
+	"Tcedilla":       0x0162,
+	"tcedilla":       0x0163,
+	"Tcaron":         0x0164,
+	"tcaron":         0x0165,
+	"Utilde":         0x0168,
+	"utilde":         0x0169,
+	"Uring":          0x016e,
+	"uring":          0x016f,
+	"Wcircumflex":    0x0174,
+	"wcircumflex":    0x0175,
+	"Ycircumflex":    0x0176,
+	"ycircumflex":    0x0177,
+	"Ydieresis":      0x0178,
+	"Zacute":         0x0179,
+	"zacute":         0x017a,
+	"Zcaron":         0x017d,
+	"zcaron":         0x017e,
+	"florin":         0x0192,
+	"circumflex":     0x02c6,
+	"caron":          0x02c7,
+	"breve":          0x02d8,
+	"dotaccent":      0x02d9,
+	"ring":           0x02da,
+	"ogonek":         0x02db,
+	"tilde":          0x02dc,
+	"hungarumlaut":   0x02dd,
+	"endash":         0x2013,
+	"emdash":         0x2014,
+	"quoteleft":      0x2018,
+	"quoteright":     0x2019,
+	"quotesinglbase": 0x201a,
+	"quotedblleft":   0x201c,
+	"quotedblright":  0x201d,
+	"quotedblbase":   0x201e,
+	"dagger":         0x2020,
+	"daggerdbl":      0x2021,
+	"bullet":         0x2022,
+	"ellipsis":       0x2026,
+	"perthousand":    0x2030,
+	"guilsinglleft":  0x2039,
+	"guilsinglright": 0x203a,
+	"fraction":       0x2044,
+	"Euro":           0x20ac,
+	"trademark":      0x2122,
+	"minus":          0x2212,
+	"fi":             0xfb01,
+	"fl":             0xfb02,
+}
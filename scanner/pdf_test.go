@@ -0,0 +1,199 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"dws/engine"
+)
+
+// flate compresses data as a FlateDecode stream would hold it.
+func flate(data string) string {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	zw.Write([]byte(data))
+	zw.Close()
+	return b.String()
+}
+
+// streamObject returns a stream object body with the given dictionary
+// entries.
+func streamObject(entries, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", entries, len(data), data)
+}
+
+// buildPDF assembles numbered objects, starting at 1, into a PDF with a
+// classic xref table. Object 1 must be the catalog.
+func buildPDF(objects []string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// buildCompressedPDF stores the objects not marked direct in an object
+// stream indexed by an xref stream with a PNG predictor. Streams cannot
+// live in object streams, so every stream object must be marked direct.
+func buildCompressedPDF(objects []string, direct map[int]bool) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+	n := len(objects)
+	objStm, xrefNum := n+1, n+2
+	type entry struct{ kind, a, c int }
+	entries := make([]entry, n+3)
+	entries[0] = entry{0, 0, 65535}
+
+	var header, body strings.Builder
+	index := 0
+	for i, obj := range objects {
+		if direct[i+1] {
+			entries[i+1] = entry{1, b.Len(), 0}
+			fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+			continue
+		}
+		fmt.Fprintf(&header, "%d %d ", i+1, body.Len())
+		body.WriteString(obj + "\n")
+		entries[i+1] = entry{2, objStm, index}
+		index++
+	}
+	entries[objStm] = entry{1, b.Len(), 0}
+	content := header.String() + body.String()
+	fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", objStm, streamObject(
+		fmt.Sprintf("/Type /ObjStm /N %d /First %d /Filter /FlateDecode", index, header.Len()), flate(content)))
+
+	entries[xrefNum] = entry{1, b.Len(), 0}
+	var rows []byte
+	prev := make([]byte, 6)
+	for _, e := range entries {
+		row := []byte{byte(e.kind), byte(e.a >> 24), byte(e.a >> 16), byte(e.a >> 8), byte(e.a), byte(e.c)}
+		// PNG "Up" predictor: each byte minus the byte above it.
+		rows = append(rows, 2)
+		for i := range row {
+			rows = append(rows, row[i]-prev[i])
+		}
+		prev = row
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", xrefNum, streamObject(fmt.Sprintf(
+		"/Type /XRef /Size %d /W [1 4 1] /Root 1 0 R /Filter /FlateDecode /DecodeParms << /Predictor 12 /Columns 6 >>",
+		len(entries)), flate(string(rows))))
+	fmt.Fprintf(&b, "startxref\n%d\n%%%%EOF\n", xref)
+	return b.Bytes()
+}
+
+func TestExtractPDFSimpleFonts(t *testing.T) {
+	page1 := "BT /F1 12 Tf 72 720 Td (Hello, raccoon!) Tj 0 -14 Td [(Second)-250(line)] TJ ET\n" +
+		"BT /F1 12 Tf 72 600 Td (New paragraph \\(escaped\\)) Tj ET"
+	page2 := "BT /F2 10 Tf 1 0 0 1 72 700 Tm 12 TL <01020304> Tj T* (caf\\351) Tj ET"
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 7 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [8 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Custom /Encoding << /BaseEncoding /WinAnsiEncoding /Differences [1 /S /e /c /r] >> >>",
+		streamObject("/Filter /FlateDecode", flate(page1)),
+		streamObject("/Filter [/ASCIIHexDecode]", fmt.Sprintf("%x>", page2)),
+	})
+
+	text, err := ExtractText(data, "doc.pdf")
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	want := "Hello, raccoon!\nSecond line\n\nNew paragraph (escaped)\n" + engine.PageBreak + "Secr\ncafé"
+	if text != want {
+		t.Fatalf("Expected %q, got %q", want, text)
+	}
+}
+
+func TestExtractPDFCompositeFonts(t *testing.T) {
+	cmap := `/CIDInit /ProcSet findresource begin
+begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+2 beginbfchar <0003> <0020> <0010> <D83DDE00> endbfchar
+1 beginbfrange <0020> <0029> <0061> endbfrange
+1 beginbfrange <0030> <0031> [<00540068> <2013>] endbfrange
+endcmap end`
+	content := "BT /F1 11 Tf 100 700 Td <00300020002100220003002300240025> Tj ET\n" +
+		"q 1 0 0 1 0 -100 cm /Fm1 Do Q"
+	form := "BT /F1 11 Tf 100 700 Td <00310010> Tj ET"
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> /XObject << /Fm1 8 0 R >> >> >>",
+		streamObject("/Filter /FlateDecode", flate(content)),
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Embedded /Encoding /Identity-H /DescendantFonts [6 0 R] /ToUnicode 7 0 R >>",
+		"<< /Type /Font /Subtype /CIDFontType2 /DW 500 /W [3 [250] 32 41 600] >>",
+		streamObject("", cmap),
+		streamObject("/Type /XObject /Subtype /Form /BBox [0 0 612 792]", form),
+	}
+	data := buildCompressedPDF(objects, map[int]bool{4: true, 7: true, 8: true})
+
+	text, err := ExtractText(data, "doc.pdf")
+	if err != nil {
+		t.Fatalf("ExtractText failed: %v", err)
+	}
+	if want := "Thabc def\n\n–😀"; text != want {
+		t.Fatalf("Expected %q, got %q", want, text)
+	}
+}
+
+func TestExtractPDFRebuildsBrokenXref(t *testing.T) {
+	data := buildPDF([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		streamObject("", "BT /F1 12 Tf 72 720 Td (recovered) Tj ET"),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	})
+	// Shift every object so the xref offsets are wrong and point
+	// startxref past the end.
+	broken := bytes.Replace(data, []byte("%PDF-1.4\n"), []byte("%PDF-1.4\n%garbage\n"), 1)
+	broken = bytes.Replace(broken, []byte("startxref\n"), []byte("startxref\n9"), 1)
+
+	text, err := ExtractText(broken, "doc.pdf")
+	if err != nil || text != "recovered" {
+		t.Fatalf("Expected recovered text, got %q, %v", text, err)
+	}
+}
+
+func TestExtractPDFErrors(t *testing.T) {
+	encrypted := buildPDF([]string{"<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>"})
+	encrypted = bytes.Replace(encrypted, []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt << /Filter /Standard >>"), 1)
+	if _, err := ExtractText(encrypted, "doc.pdf"); !errors.Is(err, ErrEncryptedDocument) {
+		t.Errorf("Expected encrypted document error, got %v", err)
+	}
+	for name, data := range map[string][]byte{
+		"not a pdf": []byte("plain text"),
+		"truncated": []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog"),
+		"no pages":  buildPDF([]string{"<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>"}),
+	} {
+		if _, err := ExtractText(data, "doc.pdf"); !errors.Is(err, errInvalidPDF) {
+			t.Errorf("%s: expected invalid PDF error, got %v", name, err)
+		}
+	}
+}
+
+func TestDecodeASCII85(t *testing.T) {
+	got, err := decodeASCII85([]byte("87cURD]i,\"Ebo80~>"))
+	if err != nil || string(got) != "Hello World!" {
+		t.Fatalf("Expected Hello World!, got %q, %v", got, err)
+	}
+	if got, _ := decodeASCII85([]byte("z~>")); !bytes.Equal(got, []byte{0, 0, 0, 0}) {
+		t.Errorf("Expected z to decode to four zero bytes, got %v", got)
+	}
+}
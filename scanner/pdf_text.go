@@ -0,0 +1,743 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"dws/engine"
+)
+
+// extractPDFText extracts the text of every page of a PDF, in page order,
+// separated by engine.PageBreak. Encrypted PDFs are decrypted with the empty
+// user password or password. Extraction stops with the context's error
+// between pages once ctx is done.
+func extractPDFText(ctx context.Context, data []byte, password string) (text string, err error) {
+	// PDFs are untrusted input with a great many ways to be malformed; a
+	// parser bug must fail the document rather than the server.
+	defer func() {
+		if r := recover(); r != nil {
+			text, err = "", fmt.Errorf("%w: %v", errInvalidPDF, r)
+		}
+	}()
+	f, err := openPDF(data)
+	if err != nil {
+		return "", err
+	}
+	if f.trailer["Encrypt"] != nil {
+		if err := f.setupCrypt(password); err != nil {
+			return "", err
+		}
+	}
+	pages := f.pages()
+	if len(pages) == 0 {
+		return "", fmt.Errorf("%w: no pages", errInvalidPDF)
+	}
+	texts := make([]string, len(pages))
+	for i, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		texts[i] = f.pageText(page)
+	}
+	return strings.Join(texts, "\n"+engine.PageBreak), nil
+}
+
+// pdfPage is a page dictionary with its inherited resources.
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages walks the page tree and returns the pages in order.
+func (f *pdfFile) pages() []pdfPage {
+	root := f.dict(f.trailer["Root"])
+	if root == nil {
+		return nil
+	}
+	var pages []pdfPage
+	visited := make(map[pdfRef]bool)
+	var walk func(node any, resources pdfDict, depth int)
+	walk = func(node any, resources pdfDict, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref] {
+				return
+			}
+			visited[ref] = true
+		}
+		d := f.dict(node)
+		if d == nil || depth > maxPDFDepth {
+			return
+		}
+		if r := f.dict(d["Resources"]); r != nil {
+			resources = r
+		}
+		if kids, ok := f.resolve(d["Kids"]).(pdfArray); ok && d["Type"] != pdfName("Page") {
+			for _, kid := range kids {
+				walk(kid, resources, depth+1)
+			}
+			return
+		}
+		pages = append(pages, pdfPage{dict: d, resources: resources})
+	}
+	walk(root["Pages"], nil, 0)
+	return pages
+}
+
+// pageText returns the text of a page, one line per line of text.
+func (f *pdfFile) pageText(page pdfPage) string {
+	var content []byte
+	switch c := f.resolve(page.dict["Contents"]).(type) {
+	case *pdfStream:
+		content, _ = f.decodeStream(c)
+	case pdfArray:
+		for _, part := range c {
+			if s, ok := f.resolve(part).(*pdfStream); ok {
+				if data, err := f.decodeStream(s); err == nil {
+					content = append(append(content, data...), '\n')
+				}
+			}
+		}
+	}
+	w := &pdfTextWriter{file: f, fonts: make(map[pdfRef]*pdfFont)}
+	w.run(content, page.resources, identityMatrix, 0)
+	lines := strings.Split(w.b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// pdfMatrix is an affine transformation [a b c d e f].
+type pdfMatrix [6]float64
+
+var identityMatrix = pdfMatrix{1, 0, 0, 1, 0, 0}
+
+// mul returns m × n, the transformation m followed by n.
+func (m pdfMatrix) mul(n pdfMatrix) pdfMatrix {
+	return pdfMatrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func translate(x, y float64) pdfMatrix {
+	return pdfMatrix{1, 0, 0, 1, x, y}
+}
+
+// pdfGraphicsState is the part of the graphics state that positions text.
+type pdfGraphicsState struct {
+	ctm       pdfMatrix
+	font      *pdfFont
+	size      float64
+	charSpace float64
+	wordSpace float64
+	scale     float64
+	leading   float64
+}
+
+// pdfTextWriter turns content stream text operators into lines of text.
+// Text shown at a different height starts a new line, and text shown
+// further along the same line than the previous text ended is separated
+// from it by a space.
+type pdfTextWriter struct {
+	file  *pdfFile
+	fonts map[pdfRef]*pdfFont
+	b     strings.Builder
+
+	started bool
+	lastY   float64
+	endX    float64
+	size    float64
+}
+
+// tjSpace is the TJ adjustment, in thousandths of an em, beyond which the
+// gap between two strings is taken to be a space.
+const tjSpace = 200
+
+func (w *pdfTextWriter) run(content []byte, resources pdfDict, ctm pdfMatrix, depth int) {
+	if depth > maxPDFDepth {
+		return
+	}
+	gs := pdfGraphicsState{ctm: ctm, scale: 100}
+	var stack []pdfGraphicsState
+	tm, tlm := identityMatrix, identityMatrix
+	var operands []any
+	l := &pdfLexer{data: content}
+	for {
+		pos := l.pos
+		obj, err := l.object()
+		if err != nil {
+			if l.pos >= len(content) {
+				return
+			}
+			// Skip what could not be parsed and resynchronize at the next
+			// operator.
+			if l.pos == pos {
+				l.pos++
+			}
+			operands = operands[:0]
+			continue
+		}
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+		switch op {
+		case "q":
+			stack = append(stack, gs)
+		case "Q":
+			if n := len(stack); n > 0 {
+				gs, stack = stack[n-1], stack[:n-1]
+			}
+		case "cm":
+			if m, ok := matrixOperand(operands); ok {
+				gs.ctm = m.mul(gs.ctm)
+			}
+		case "BT":
+			tm, tlm = identityMatrix, identityMatrix
+		case "Tf":
+			if len(operands) == 2 {
+				if name, ok := operands[0].(pdfName); ok {
+					gs.font = w.font(resources, name)
+				}
+				gs.size = number(operands[1])
+			}
+		case "Tc":
+			gs.charSpace = lastNumber(operands)
+		case "Tw":
+			gs.wordSpace = lastNumber(operands)
+		case "Tz":
+			gs.scale = lastNumber(operands)
+		case "TL":
+			gs.leading = lastNumber(operands)
+		case "Td", "TD":
+			if len(operands) == 2 {
+				tx, ty := number(operands[0]), number(operands[1])
+				if op == "TD" {
+					gs.leading = -ty
+				}
+				tlm = translate(tx, ty).mul(tlm)
+				tm = tlm
+			}
+		case "Tm":
+			if m, ok := matrixOperand(operands); ok {
+				tlm, tm = m, m
+			}
+		case "T*":
+			tlm = translate(0, -gs.leading).mul(tlm)
+			tm = tlm
+		case "Tj", "'", "\"":
+			if op != "Tj" {
+				if op == "\"" && len(operands) == 3 {
+					gs.wordSpace, gs.charSpace = number(operands[0]), number(operands[1])
+				}
+				tlm = translate(0, -gs.leading).mul(tlm)
+				tm = tlm
+			}
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					tm = w.show(s, &gs, tm)
+				}
+			}
+		case "TJ":
+			if len(operands) == 1 {
+				if arr, ok := operands[0].(pdfArray); ok {
+					for _, item := range arr {
+						if s, ok := item.(pdfString); ok {
+							tm = w.show(s, &gs, tm)
+							continue
+						}
+						adjust := number(item)
+						tm = translate(-adjust/1000*gs.size*gs.scale/100, 0).mul(tm)
+						if adjust <= -tjSpace {
+							w.space()
+						}
+					}
+				}
+			}
+		case "Do":
+			if len(operands) == 1 {
+				if name, ok := operands[0].(pdfName); ok {
+					w.form(resources, name, gs.ctm, depth)
+				}
+			}
+		case "BI":
+			skipInlineImage(l)
+		}
+		operands = operands[:0]
+	}
+}
+
+// show writes a string in the current font and returns the text matrix
+// advanced past it.
+func (w *pdfTextWriter) show(s pdfString, gs *pdfGraphicsState, tm pdfMatrix) pdfMatrix {
+	if gs.font == nil {
+		gs.font = defaultPDFFont
+	}
+	trm := tm.mul(gs.ctm)
+	x, y := trm[4], trm[5]
+	size := gs.size * math.Hypot(trm[2], trm[3])
+	if size <= 0 {
+		size = 1
+	}
+	if w.started {
+		switch dy := math.Abs(y - w.lastY); {
+		case dy > 2*max(size, w.size):
+			w.b.WriteString("\n\n")
+		case dy > 0.5*min(size, w.size):
+			w.b.WriteByte('\n')
+		case x > w.endX+0.15*size:
+			w.space()
+		}
+	}
+	for _, g := range gs.font.decode(s) {
+		w.b.WriteString(g.text)
+		advance := g.width/1000*gs.size + gs.charSpace
+		if g.space {
+			advance += gs.wordSpace
+		}
+		tm = translate(advance*gs.scale/100, 0).mul(tm)
+	}
+	w.started = true
+	w.lastY, w.size = y, size
+	w.endX = tm.mul(gs.ctm)[4]
+	return tm
+}
+
+// space separates the next text from the text written so far.
+func (w *pdfTextWriter) space() {
+	s := w.b.String()
+	if s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+		w.b.WriteByte(' ')
+	}
+}
+
+// form interprets a form XObject, which draws content defined once and
+// reused, such as page headers.
+func (w *pdfTextWriter) form(resources pdfDict, name pdfName, ctm pdfMatrix, depth int) {
+	xobjects := w.file.dict(resources["XObject"])
+	stream, ok := w.file.resolve(xobjects[name]).(*pdfStream)
+	if !ok || stream.dict["Subtype"] != pdfName("Form") {
+		return
+	}
+	data, err := w.file.decodeStream(stream)
+	if err != nil {
+		return
+	}
+	if m, ok := matrixOperand(w.file.resolve(stream.dict["Matrix"])); ok {
+		ctm = m.mul(ctm)
+	}
+	if r := w.file.dict(stream.dict["Resources"]); r != nil {
+		resources = r
+	}
+	w.run(data, resources, ctm, depth+1)
+}
+
+// skipInlineImage moves past the data of an inline image, which follows
+// its "ID" operator and ends at "EI".
+func skipInlineImage(l *pdfLexer) {
+	for {
+		tok, err := l.token()
+		if err != nil {
+			return
+		}
+		if tok == pdfKeyword("ID") {
+			break
+		}
+	}
+	for i := l.pos + 1; i+2 <= len(l.data); i++ {
+		if l.data[i] == 'E' && l.data[i+1] == 'I' && isPDFSpace(l.data[i-1]) &&
+			(i+2 == len(l.data) || isPDFSpace(l.data[i+2])) {
+			l.pos = i + 2
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
+
+func number(v any) float64 {
+	switch n := v.(type) {
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+func lastNumber(operands []any) float64 {
+	if len(operands) == 0 {
+		return 0
+	}
+	return number(operands[len(operands)-1])
+}
+
+// matrixOperand reads a matrix from six operands or a six-element array.
+func matrixOperand(v any) (pdfMatrix, bool) {
+	var items []any
+	switch t := v.(type) {
+	case []any:
+		items = t
+	case pdfArray:
+		items = t
+	}
+	if len(items) != 6 {
+		return pdfMatrix{}, false
+	}
+	var m pdfMatrix
+	for i, item := range items {
+		m[i] = number(item)
+	}
+	return m, true
+}
+
+// pdfGlyph is a decoded character code.
+type pdfGlyph struct {
+	text  string
+	width float64 // in thousandths of an em
+	space bool    // single-byte code 32, which word spacing applies to
+}
+
+// pdfFont decodes the strings shown in a font to Unicode.
+type pdfFont struct {
+	composite bool
+	// codespace gives the byte lengths of character codes. Without one,
+	// codes are one byte in simple fonts and two in composite fonts.
+	codespace []codespaceRange
+	toUnicode map[string]string
+	encoding  [256]rune
+	widths    map[int]float64
+	defWidth  float64
+}
+
+type codespaceRange struct {
+	lo, hi []byte
+}
+
+// defaultPDFFont decodes text shown before any font is selected.
+var defaultPDFFont = &pdfFont{encoding: standardEncoding, defWidth: 500}
+
+// font loads a font from the page resources.
+func (w *pdfTextWriter) font(resources pdfDict, name pdfName) *pdfFont {
+	fonts := w.file.dict(resources["Font"])
+	ref, isRef := fonts[name].(pdfRef)
+	if isRef {
+		if font, ok := w.fonts[ref]; ok {
+			return font
+		}
+	}
+	font := w.file.loadFont(w.file.dict(fonts[name]))
+	if isRef {
+		w.fonts[ref] = font
+	}
+	return font
+}
+
+func (f *pdfFile) loadFont(d pdfDict) *pdfFont {
+	if d == nil {
+		return defaultPDFFont
+	}
+	font := &pdfFont{widths: make(map[int]float64)}
+	if s, ok := f.resolve(d["ToUnicode"]).(*pdfStream); ok {
+		if data, err := f.decodeStream(s); err == nil {
+			font.codespace, font.toUnicode = parseCMap(data)
+		}
+	}
+	if d["Subtype"] == pdfName("Type0") {
+		font.composite = true
+		font.defWidth = 1000
+		if s, ok := f.resolve(d["Encoding"]).(*pdfStream); ok {
+			if data, err := f.decodeStream(s); err == nil {
+				if cs, _ := parseCMap(data); len(cs) > 0 {
+					font.codespace = cs
+				}
+			}
+		}
+		if descendants, ok := f.resolve(d["DescendantFonts"]).(pdfArray); ok && len(descendants) > 0 {
+			cid := f.dict(descendants[0])
+			if dw, ok := f.resolve(cid["DW"]).(int64); ok {
+				font.defWidth = float64(dw)
+			}
+			f.loadCIDWidths(font, f.resolve(cid["W"]))
+		}
+		return font
+	}
+
+	font.encoding = standardEncoding
+	base, _ := f.resolve(d["BaseFont"]).(pdfName)
+	font.defWidth = 500
+	if strings.Contains(string(base), "Courier") {
+		font.defWidth = 600
+	}
+	if d["Subtype"] == pdfName("TrueType") {
+		font.encoding = winAnsiEncoding
+	}
+	switch enc := f.resolve(d["Encoding"]).(type) {
+	case pdfName:
+		font.encoding = namedEncoding(enc, font.encoding)
+	case pdfDict:
+		if name, ok := f.resolve(enc["BaseEncoding"]).(pdfName); ok {
+			font.encoding = namedEncoding(name, font.encoding)
+		}
+		if diffs, ok := f.resolve(enc["Differences"]).(pdfArray); ok {
+			code := 0
+			for _, item := range diffs {
+				switch v := f.resolve(item).(type) {
+				case int64:
+					code = int(v)
+				case pdfName:
+					if code >= 0 && code < 256 {
+						font.encoding[code] = glyphRune(string(v))
+					}
+					code++
+				}
+			}
+		}
+	}
+	first, _ := f.resolve(d["FirstChar"]).(int64)
+	if widths, ok := f.resolve(d["Widths"]).(pdfArray); ok {
+		for i, width := range widths {
+			font.widths[int(first)+i] = number(f.resolve(width))
+		}
+	}
+	return font
+}
+
+// loadCIDWidths reads the /W array of a CIDFont: "c [w1 w2 ...]" gives
+// widths for consecutive CIDs from c, and "c1 c2 w" one width for a range.
+func (f *pdfFile) loadCIDWidths(font *pdfFont, w any) {
+	arr, _ := w.(pdfArray)
+	for i := 0; i < len(arr); {
+		start, ok := f.resolve(arr[i]).(int64)
+		if !ok || i+1 >= len(arr) {
+			return
+		}
+		if list, ok := f.resolve(arr[i+1]).(pdfArray); ok {
+			for j, width := range list {
+				font.widths[int(start)+j] = number(f.resolve(width))
+			}
+			i += 2
+			continue
+		}
+		end, ok := f.resolve(arr[i+1]).(int64)
+		if !ok || i+2 >= len(arr) || end-start > 1<<16 {
+			return
+		}
+		width := number(f.resolve(arr[i+2]))
+		for c := start; c <= end; c++ {
+			font.widths[int(c)] = width
+		}
+		i += 3
+	}
+}
+
+func namedEncoding(name pdfName, fallback [256]rune) [256]rune {
+	switch name {
+	case "WinAnsiEncoding":
+		return winAnsiEncoding
+	case "MacRomanEncoding":
+		return macRomanEncoding
+	case "StandardEncoding":
+		return standardEncoding
+	}
+	return fallback
+}
+
+// glyphRune maps a glyph name to Unicode, returning zero for names it does
+// not know.
+func glyphRune(name string) rune {
+	if r, ok := glyphNames[name]; ok {
+		return r
+	}
+	if hex, ok := strings.CutPrefix(name, "uni"); ok && len(hex) >= 4 && len(hex)%4 == 0 {
+		if v, err := strconv.ParseUint(hex[:4], 16, 32); err == nil {
+			return rune(v)
+		}
+	}
+	if hex, ok := strings.CutPrefix(name, "u"); ok && len(hex) >= 4 && len(hex) <= 6 {
+		if v, err := strconv.ParseUint(hex, 16, 32); err == nil {
+			return rune(v)
+		}
+	}
+	return 0
+}
+
+// decode splits a shown string into character codes and maps them to text.
+func (f *pdfFont) decode(s pdfString) []pdfGlyph {
+	var glyphs []pdfGlyph
+	for i := 0; i < len(s); {
+		n := f.codeLength(s[i:])
+		code := s[i:min(i+n, len(s))]
+		i += n
+		c := 0
+		for j := 0; j < len(code); j++ {
+			c = c<<8 | int(code[j])
+		}
+		g := pdfGlyph{width: f.defWidth, space: len(code) == 1 && c == ' '}
+		if w, ok := f.widths[c]; ok {
+			g.width = w
+		}
+		if text, ok := f.toUnicode[string(code)]; ok {
+			g.text = text
+		} else if !f.composite {
+			if r := f.encoding[c]; r != 0 {
+				g.text = string(r)
+			}
+		}
+		glyphs = append(glyphs, g)
+	}
+	return glyphs
+}
+
+// codeLength returns the length of the character code at the start of s.
+func (f *pdfFont) codeLength(s pdfString) int {
+	for n := 1; n <= 4 && n <= len(s); n++ {
+		for _, r := range f.codespace {
+			if len(r.lo) != n {
+				continue
+			}
+			code := []byte(s[:n])
+			if bytes.Compare(code, r.lo) >= 0 && bytes.Compare(code, r.hi) <= 0 {
+				return n
+			}
+		}
+	}
+	if f.composite {
+		return 2
+	}
+	return 1
+}
+
+// maxCMapRange caps the codes expanded from one bfrange entry.
+const maxCMapRange = 1 << 16
+
+// parseCMap reads the codespace ranges and the code-to-Unicode mappings of
+// a CMap.
+func parseCMap(data []byte) ([]codespaceRange, map[string]string) {
+	var codespace []codespaceRange
+	chars := make(map[string]string)
+	l := &pdfLexer{data: data}
+	var operands []any
+	mode := ""
+	for {
+		obj, err := l.object()
+		if err != nil {
+			if l.pos >= len(data) {
+				return codespace, chars
+			}
+			l.pos++
+			continue
+		}
+		kw, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			switch mode {
+			case "codespacerange":
+				if len(operands) == 2 {
+					lo, _ := operands[0].(pdfString)
+					hi, _ := operands[1].(pdfString)
+					if len(lo) > 0 && len(lo) == len(hi) {
+						codespace = append(codespace, codespaceRange{lo: []byte(lo), hi: []byte(hi)})
+					}
+					operands = operands[:0]
+				}
+			case "bfchar":
+				if len(operands) == 2 {
+					src, _ := operands[0].(pdfString)
+					chars[string(src)] = cmapText(operands[1])
+					operands = operands[:0]
+				}
+			case "bfrange":
+				if len(operands) == 3 {
+					addBFRange(chars, operands)
+					operands = operands[:0]
+				}
+			}
+			continue
+		}
+		switch kw {
+		case "begincodespacerange", "beginbfchar", "beginbfrange":
+			mode = strings.TrimPrefix(string(kw), "begin")
+		case "endcodespacerange", "endbfchar", "endbfrange":
+			mode = ""
+		}
+		operands = operands[:0]
+	}
+}
+
+// addBFRange maps the codes lo through hi either to consecutive Unicode
+// values from a starting string or to the strings of an array.
+func addBFRange(chars map[string]string, operands []any) {
+	lo, ok1 := operands[0].(pdfString)
+	hi, ok2 := operands[1].(pdfString)
+	if !ok1 || !ok2 || len(lo) == 0 || len(lo) != len(hi) || len(lo) > 4 {
+		return
+	}
+	start, end := codeValue(lo), codeValue(hi)
+	if end < start || end-start >= maxCMapRange {
+		return
+	}
+	arr, isArray := operands[2].(pdfArray)
+	dst, _ := operands[2].(pdfString)
+	units := utf16Units(dst)
+	for c := start; c <= end; c++ {
+		code := make([]byte, len(lo))
+		for i, v := len(code)-1, c; i >= 0; i, v = i-1, v>>8 {
+			code[i] = byte(v)
+		}
+		if isArray {
+			if i := int(c - start); i < len(arr) {
+				chars[string(code)] = cmapText(arr[i])
+			}
+			continue
+		}
+		if len(units) == 0 {
+			continue
+		}
+		u := append([]uint16(nil), units...)
+		u[len(u)-1] += uint16(c - start)
+		chars[string(code)] = string(utf16.Decode(u))
+	}
+}
+
+func codeValue(s pdfString) uint32 {
+	var v uint32
+	for i := 0; i < len(s); i++ {
+		v = v<<8 | uint32(s[i])
+	}
+	return v
+}
+
+// cmapText decodes a CMap destination: UTF-16BE text or a glyph name.
+func cmapText(v any) string {
+	switch t := v.(type) {
+	case pdfString:
+		return string(utf16.Decode(utf16Units(t)))
+	case pdfName:
+		if r := glyphRune(string(t)); r != 0 {
+			return string(r)
+		}
+	}
+	return ""
+}
+
+func utf16Units(s pdfString) []uint16 {
+	units := make([]uint16, len(s)/2)
+	for i := range units {
+		units[i] = uint16(s[2*i])<<8 | uint16(s[2*i+1])
+	}
+	if len(s)%2 == 1 {
+		units = append(units, uint16(s[len(s)-1]))
+	}
+	return units
+}
@@ -0,0 +1,241 @@
+package scanner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+var errInvalidRTF = errors.New("invalid RTF document")
+
+// maxRTFDepth bounds the nesting of RTF groups.
+const maxRTFDepth = 256
+
+// rtfSkipDestinations are the destinations whose groups hold no document
+// text: tables, metadata, pictures, embedded objects and field
+// instructions.
+var rtfSkipDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true,
+	"listtable": true, "listoverridetable": true, "revtbl": true, "rsidtbl": true,
+	"generator": true, "xmlnstbl": true, "latentstyles": true, "datastore": true,
+	"themedata": true, "colorschememapping": true, "pict": true, "object": true,
+	"objdata": true, "fldinst": true, "filetbl": true, "pgdsctbl": true,
+	"mmathPr": true, "bkmkstart": true, "bkmkend": true, "nonshppict": true,
+	"private": true, "fchars": true, "lchars": true,
+}
+
+// rtfInfoText are the destinations of the \info group whose text is
+// extracted, each on its own line. Authors, dates and statistics are not.
+var rtfInfoText = map[string]bool{"title": true, "subject": true, "keywords": true}
+
+// rtfSymbols maps control words that stand for a character to its text.
+var rtfSymbols = map[string]string{
+	"par": "\n", "line": "\n", "row": "\n", "sect": "\n", "page": "\n",
+	"tab": "\t", "cell": "\t",
+	"emdash": "—", "endash": "–", "bullet": "•",
+	"lquote": "‘", "rquote": "’", "ldblquote": "“", "rdblquote": "”",
+	"emspace": " ", "enspace": " ", "qmspace": " ",
+}
+
+// rtfState is the state of an RTF group, inherited by nested groups.
+type rtfState struct {
+	skip bool // inside a destination without document text
+	line bool // a metadata field, ended by a line break
+	uc   int  // number of fallback characters following \uN
+}
+
+// extractRTFText extracts the text of an RTF document: its title, subject
+// and keywords, body, headers, footers, footnotes and field results.
+// Control words are dropped, \'hh escapes are decoded with the document's
+// code page (Windows-1252 unless it declares Mac Roman), \uN escapes
+// replace their fallback characters, and pictures, embedded objects and
+// \bin data are skipped.
+func extractRTFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte(`{\rtf`)) {
+		return "", fmt.Errorf("%w: missing {\\rtf header", errInvalidRTF)
+	}
+	var b strings.Builder
+	codepage := &winAnsiEncoding
+	stack := []rtfState{{uc: 1}}
+	// skipChars counts the fallback characters still to drop after \uN.
+	skipChars := 0
+	// pending holds the high half of a UTF-16 surrogate pair.
+	var pending rune
+
+	emit := func(s string) {
+		if stack[len(stack)-1].skip {
+			return
+		}
+		if pending != 0 {
+			b.WriteRune(unicode.ReplacementChar)
+			pending = 0
+		}
+		b.WriteString(s)
+	}
+	emitByte := func(c byte) {
+		if skipChars > 0 {
+			skipChars--
+			return
+		}
+		r := codepage[c]
+		if r == 0 {
+			r = rune(c)
+		}
+		emit(string(r))
+	}
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch c {
+		case '{':
+			if len(stack) >= maxRTFDepth {
+				return "", fmt.Errorf("%w: groups nested too deeply", errInvalidRTF)
+			}
+			state := stack[len(stack)-1]
+			state.line = false
+			stack = append(stack, state)
+			skipChars = 0
+			i++
+		case '}':
+			if stack[len(stack)-1].line {
+				emit("\n")
+			}
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			skipChars = 0
+			i++
+		case '\r', '\n':
+			i++
+		case '\\':
+			i++
+			if i >= len(data) {
+				break
+			}
+			c = data[i]
+			if !isASCIILetter(c) {
+				// A control symbol.
+				i++
+				switch c {
+				case '\\', '{', '}':
+					emitByte(c)
+				case '\'':
+					if i+2 <= len(data) {
+						if hi, ok1 := unhex(data[i]); ok1 {
+							if lo, ok2 := unhex(data[i+1]); ok2 {
+								emitByte(hi<<4 | lo)
+							}
+						}
+						i += 2
+					}
+				case '~':
+					// A non-breaking space, written as a plain space so
+					// that patterns matching spaces match it.
+					emit(" ")
+				case '_':
+					emit("-")
+				case '*':
+					// An ignorable destination, which readers that do not
+					// know it skip.
+					stack[len(stack)-1].skip = true
+				case '\r', '\n':
+					emit("\n")
+				}
+				break
+			}
+			word, param, next := rtfControlWord(data, i)
+			i = next
+			switch {
+			case word == "bin":
+				// Binary data follows the space delimiter.
+				if param > 0 {
+					i = min(i+param, len(data))
+				}
+			case word == "u":
+				r := rune(int16(param))
+				if r < 0 {
+					r += 0x10000
+				}
+				skipChars = stack[len(stack)-1].uc
+				if stack[len(stack)-1].skip {
+					break
+				}
+				switch {
+				case utf16.IsSurrogate(r) && r < 0xdc00:
+					if pending != 0 {
+						b.WriteRune(unicode.ReplacementChar)
+					}
+					pending = r
+				case utf16.IsSurrogate(r) && pending != 0:
+					b.WriteRune(utf16.DecodeRune(pending, r))
+					pending = 0
+				default:
+					emit(string(r))
+				}
+			case word == "uc":
+				stack[len(stack)-1].uc = max(param, 0)
+			case word == "mac":
+				codepage = &macRomanEncoding
+			case word == "ansicpg":
+				if param == 10000 {
+					codepage = &macRomanEncoding
+				}
+			case rtfSkipDestinations[word]:
+				stack[len(stack)-1].skip = true
+			case rtfInfoText[word]:
+				stack[len(stack)-1].skip = false
+				stack[len(stack)-1].line = true
+			default:
+				if s, ok := rtfSymbols[word]; ok {
+					if skipChars > 0 {
+						skipChars--
+						break
+					}
+					emit(s)
+				}
+			}
+		default:
+			emitByte(c)
+			i++
+		}
+	}
+	if pending != 0 {
+		b.WriteRune(unicode.ReplacementChar)
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// rtfControlWord reads the control word starting at data[i], after its
+// backslash, and its optional numeric parameter. A single space delimiting
+// the word is consumed. It returns the index after the word.
+func rtfControlWord(data []byte, i int) (string, int, int) {
+	start := i
+	for i < len(data) && isASCIILetter(data[i]) {
+		i++
+	}
+	word := string(data[start:i])
+	param, neg, digits := 0, false, 0
+	if i < len(data) && data[i] == '-' {
+		neg = true
+		i++
+	}
+	for i < len(data) && data[i] >= '0' && data[i] <= '9' && digits < 10 {
+		param = param*10 + int(data[i]-'0')
+		digits++
+		i++
+	}
+	if neg {
+		param = -param
+	}
+	if i < len(data) && data[i] == ' ' {
+		i++
+	}
+	return word, param, i
+}
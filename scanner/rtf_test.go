@@ -0,0 +1,71 @@
+package scanner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtractRTF(t *testing.T) {
+	tests := []struct {
+		name string
+		rtf  string
+		want string
+	}{
+		{
+			"paragraphs and tables",
+			`{\rtf1\ansi\deff0{\fonttbl{\f0 Times New Roman;}}{\colortbl;\red0\green0\blue0;}` + "\r\n" +
+				`{\info{\title Secret title}{\author Bob}}\f0\fs24 Hello, \b raccoon\b0 !\par` + "\r\n" +
+				`Name\tab Card\cell 4111\row Braces \{ and \} and \\ slash\line next}`,
+			"Secret title\nHello, raccoon!\nName\tCard\t4111\nBraces { and } and \\ slash\nnext",
+		},
+		{
+			"hex and unicode escapes",
+			`{\rtf1\ansi\ansicpg1252 caf\'e9 \'93quoted\'94 \u8364? euro \uc2\u1055\'cf\'f0!{\uc0\u1082}\uc1 \u-10179?\u-8704?}`,
+			"café “quoted” € euro П!к😀",
+		},
+		{
+			"metadata",
+			`{\rtf1{\info{\title Q3 plan}{\subject Operation {\b Raccoon}}{\author Bob}{\keywords secret, caf\'e9}{\creatim\yr2024\mo1}}Body}`,
+			"Q3 plan\nOperation Raccoon\nsecret, café\nBody",
+		},
+		{
+			"mac roman",
+			`{\rtf1\mac caf\'8e}`,
+			"café",
+		},
+		{
+			"skipped destinations",
+			`{\rtf1{\*\generator Word;}{\stylesheet{\s0 Normal;}}Visible {\*\ignored hidden}{\field{\*\fldinst HYPERLINK "http://x"}{\fldrslt link text}} end` +
+				`{\pict\pngblip 89504e47}{\object{\*\objdata 0102}} {\header Header banner\par}\par ` +
+				`bin \bin4 {}\\ after}`,
+			"Visible link text end Header banner\n\nbin  after",
+		},
+		{
+			"symbols",
+			`{\rtf1 a\emdash b\endash c\bullet \lquote q\rquote  \ldblquote dq\rdblquote\~x\_y\-z}`,
+			"a—b–c•‘q’ “dq” x-yz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractText([]byte(tt.rtf), "doc.rtf")
+			if err != nil {
+				t.Fatalf("ExtractText failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExtractRTFErrors(t *testing.T) {
+	if _, err := ExtractText([]byte("plain text"), "doc.rtf"); !errors.Is(err, errInvalidRTF) {
+		t.Errorf("Expected invalid RTF error, got %v", err)
+	}
+	deep := `{\rtf1` + strings.Repeat("{", maxRTFDepth)
+	if _, err := ExtractText([]byte(deep), "doc.rtf"); !errors.Is(err, errInvalidRTF) {
+		t.Errorf("Expected nesting error, got %v", err)
+	}
+}